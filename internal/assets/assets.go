@@ -1,6 +1,7 @@
 package assets
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -15,10 +16,14 @@ import (
 	"github.com/chai2010/webp"
 	"github.com/jaysongiroux/mdserve/internal/config"
 	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/metrics"
 	"github.com/jaysongiroux/mdserve/internal/routines"
 	"golang.org/x/sync/errgroup"
 )
 
+// assetsLogger logs the messages of the assets service
+var assetsLogger = logger.Service("assets")
+
 var (
 	NonOptimizableImageRegexes = []*regexp.Regexp{
 		regexp.MustCompile(`apple-touch-icon.[A-z]*`),
@@ -27,31 +32,35 @@ var (
 )
 
 func OptimizeAssets(
+	ctx context.Context,
 	assets []string,
 	siteManifestIconPaths []string,
 	serverConfig *config.ServerConfig,
 ) error {
 	if len(assets) == 0 {
-		logger.Debug("No assets to optimize")
+		assetsLogger.Ctx(ctx).Debug("No assets to optimize")
 		return nil
 	}
 
 	// Calculate optimal number of workers
 	maxWorkers := routines.CalculateMaxWorkers(len(assets))
-	logger.Info("Optimizing %d assets with %d concurrent workers", len(assets), maxWorkers)
+	assetsLogger.Ctx(ctx).Info("Optimizing %d assets with %d concurrent workers", len(assets), maxWorkers)
 
-	// Create error group with limit
-	g := new(errgroup.Group)
+	// Create error group with limit, a cancelled build stops the remaining workers
+	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxWorkers)
 
 	// Process each asset concurrently
 	for i, asset := range assets {
 		g.Go(func() error {
-			logger.Info("[Worker %d] Checking asset: %s", i, asset)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			assetsLogger.Ctx(ctx).Info("[Worker %d] Checking asset: %s", i, asset)
 
 			// Filter out assets that should be skipped
 			if shouldSkipAsset(asset, siteManifestIconPaths) {
-				logger.Info(
+				assetsLogger.Ctx(ctx).Info(
 					"[Worker %d] Skipping asset: %s because it is a site manifest icon, favicon, SVG, or a format that is not supported for optimization",
 					i,
 					asset,
@@ -65,28 +74,28 @@ func OptimizeAssets(
 				serverConfig.OptimizeImages,
 				serverConfig.OptimizeImagesQuality,
 			); err != nil {
-				logger.Error("[Worker %d] Failed to convert asset to WebP: %s - %v", i, asset, err)
+				assetsLogger.Ctx(ctx).Error("[Worker %d] Failed to convert asset to WebP: %s - %v", i, asset, err)
 				return fmt.Errorf("failed to convert asset %s to WebP: %w", asset, err)
 			}
 
 			// Delete original asset
 			if err := DeleteAsset(asset); err != nil {
-				logger.Error("[Worker %d] Failed to delete asset: %s - %v", i, asset, err)
+				assetsLogger.Ctx(ctx).Error("[Worker %d] Failed to delete asset: %s - %v", i, asset, err)
 				return fmt.Errorf("failed to delete asset %s: %w", asset, err)
 			}
 
-			logger.Debug("[Worker %d] Successfully optimized asset: %s", i, asset)
+			assetsLogger.Ctx(ctx).Debug("[Worker %d] Successfully optimized asset: %s", i, asset)
 			return nil
 		})
 	}
 
 	// Wait for all goroutines to complete
 	if err := g.Wait(); err != nil {
-		logger.Fatal("[Worker %d] Asset optimization failed: %v", err)
+		assetsLogger.Ctx(ctx).Error("Asset optimization failed: %v", err)
 		return err
 	}
 
-	logger.Info("Successfully optimized all assets")
+	assetsLogger.Ctx(ctx).Info("Successfully optimized all assets")
 	return nil
 }
 
@@ -100,7 +109,7 @@ func convertToWebP(path string, optimize bool, quality int) error {
 	defer func() {
 		err := file.Close()
 		if err != nil {
-			logger.Error("Failed to close file: %v", err)
+			assetsLogger.Error("Failed to close file: %v", err)
 		}
 	}()
 
@@ -108,7 +117,7 @@ func convertToWebP(path string, optimize bool, quality int) error {
 	img, _, err := image.Decode(file)
 	if err != nil {
 		// If decode fails (e.g. corrupted image), we log and skip
-		logger.Error("Skipping %s: could not decode", path)
+		assetsLogger.Error("Skipping %s: could not decode", path)
 		return err
 	}
 
@@ -123,7 +132,7 @@ func convertToWebP(path string, optimize bool, quality int) error {
 	defer func() {
 		err := outFile.Close()
 		if err != nil {
-			logger.Error("Failed to close output file: %v", err)
+			assetsLogger.Error("Failed to close output file: %v", err)
 		}
 	}()
 
@@ -138,7 +147,13 @@ func convertToWebP(path string, optimize bool, quality int) error {
 		return err
 	}
 
-	logger.Info("Generated WebP: %s", webpPath)
+	if original, err := file.Stat(); err == nil {
+		if converted, err := outFile.Stat(); err == nil {
+			metrics.AddWebPBytesSaved(original.Size() - converted.Size())
+		}
+	}
+
+	assetsLogger.Info("Generated WebP: %s", webpPath)
 	return nil
 }
 
@@ -0,0 +1,281 @@
+// Package build implements blue/green generation of the site. Each generation
+// is built into its own directory under <generated_path>/builds, validated,
+// then made live by atomically replacing the <generated_path>/current symlink.
+// The server reads the files of the build of its app snapshot, so requests
+// never see a partial build. Older builds are kept for rollbacks, and the build
+// of the previous snapshot is kept for the requests it is still serving
+package build
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+// buildLogger logs the messages of the build service
+var buildLogger = logger.Service("build")
+
+// build ids sort in creation order
+const idFormat = "20060102T150405.000000Z"
+
+// ErrBuildNotFound is returned when a build id has no completed build
+var ErrBuildNotFound = errors.New("build not found")
+
+// Build is a generation of the site in its own directory
+type Build struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Pages      int       `json:"pages"`
+	// name of what triggered the build, ex. Generation Cron
+	Trigger string `json:"trigger,omitempty"`
+	// commit of the content repository the build was generated from
+	Commit string `json:"commit,omitempty"`
+	// warnings and errors logged during the build
+	Warnings []string `json:"warnings,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+	// why the build failed, failed builds are only kept in memory
+	Error string `json:"error,omitempty"`
+	// directory of the build, <generated_path>/builds/<id>
+	Path string `json:"-"`
+}
+
+// New creates the directory of a new build under generatedPath
+func New(generatedPath string) (*Build, error) {
+	buildsPath := filepath.Join(generatedPath, constants.BuildsPath)
+	if err := os.MkdirAll(buildsPath, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create builds directory %s: %w", buildsPath, err)
+	}
+
+	startedAt := time.Now().UTC()
+	for {
+		id := startedAt.Format(idFormat)
+		buildPath := filepath.Join(buildsPath, id)
+		err := os.Mkdir(buildPath, 0750)
+		if err == nil {
+			return &Build{ID: id, StartedAt: startedAt, Path: buildPath}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create build directory %s: %w", buildPath, err)
+		}
+		// another build started within the same microsecond
+		startedAt = startedAt.Add(time.Microsecond)
+	}
+}
+
+// Finish records the build as completed by writing its manifest. Only
+// finished builds can be activated
+func (b *Build) Finish(pages int) error {
+	b.FinishedAt = time.Now().UTC()
+	b.Pages = pages
+
+	manifest, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := filepath.Join(b.Path, constants.BuildManifestPath)
+	if err := os.WriteFile(manifestPath, manifest, 0600); err != nil {
+		return fmt.Errorf("failed to write build manifest %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// Remove deletes the directory of the build, used to discard failed builds
+func (b *Build) Remove() error {
+	return os.RemoveAll(b.Path)
+}
+
+// Duration returns how long the build took
+func (b *Build) Duration() time.Duration {
+	if b.FinishedAt.IsZero() {
+		return 0
+	}
+	return b.FinishedAt.Sub(b.StartedAt)
+}
+
+// Validate checks that a build holds everything the server reads: a site map
+// without duplicate page paths, the default and error templates and, in static
+// mode, the HTML of every page
+func Validate(buildPath string, serverConfig *config.ServerConfig) error {
+	siteMap, err := htmlcompiler.LoadSiteMap(filepath.Join(buildPath, constants.SiteMapPath))
+	if err != nil {
+		return fmt.Errorf("invalid site map: %w", err)
+	}
+
+	pageSources := map[string]string{}
+	for _, entry := range *siteMap {
+		if source, ok := pageSources[entry.Path]; ok {
+			return fmt.Errorf("pages %s and %s have the same path %s", source, entry.SourcePath, entry.Path)
+		}
+		pageSources[entry.Path] = entry.SourcePath
+	}
+
+	for _, template := range []string{"layout.html", "error.html"} {
+		templatePath := filepath.Join(buildPath, constants.TemplatesPath, template)
+		if _, err := os.Stat(templatePath); err != nil {
+			return fmt.Errorf("missing template %s: %w", template, err)
+		}
+	}
+
+	if serverConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
+		for _, entry := range *siteMap {
+			htmlPath := filepath.Join(buildPath, constants.HTMLFilesPath, entry.Path+".html")
+			if _, err := os.Stat(htmlPath); err != nil {
+				return fmt.Errorf("missing HTML file for page %s: %w", entry.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Activate atomically points the live build symlink of generatedPath at a finished build
+func Activate(generatedPath string, id string) error {
+	if _, err := Load(generatedPath, id); err != nil {
+		return err
+	}
+
+	livePath := filepath.Join(generatedPath, constants.LiveBuildPath)
+	tempPath := livePath + ".tmp"
+	if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale symlink %s: %w", tempPath, err)
+	}
+
+	// the target is relative so the generated path can be moved or mounted elsewhere
+	if err := os.Symlink(filepath.Join(constants.BuildsPath, id), tempPath); err != nil {
+		return fmt.Errorf("failed to create symlink to build %s: %w", id, err)
+	}
+
+	// rename replaces the previous symlink in a single step
+	if err := os.Rename(tempPath, livePath); err != nil {
+		return fmt.Errorf("failed to activate build %s: %w", id, err)
+	}
+
+	buildLogger.Info("Build %s is live", id)
+	return nil
+}
+
+// Current returns the id of the live build of generatedPath
+func Current(generatedPath string) (string, error) {
+	target, err := os.Readlink(filepath.Join(generatedPath, constants.LiveBuildPath))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// List returns the finished builds of generatedPath, newest first
+func List(generatedPath string) ([]Build, error) {
+	entries, err := os.ReadDir(filepath.Join(generatedPath, constants.BuildsPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	var builds []Build
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		build, err := Load(generatedPath, entry.Name())
+		if err != nil {
+			continue
+		}
+		builds = append(builds, *build)
+	}
+
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].ID > builds[j].ID
+	})
+	return builds, nil
+}
+
+// Rollback makes a previous finished build live again
+func Rollback(generatedPath string, id string) error {
+	current, err := Current(generatedPath)
+	if err == nil && current == id {
+		return fmt.Errorf("build %s is already live", id)
+	}
+	return Activate(generatedPath, id)
+}
+
+// Prune deletes the oldest builds of generatedPath so that at most keep
+// builds remain, never deleting the live build nor the builds inUse, ex. the
+// build of the previous snapshot whose requests in flight read its files.
+// Unfinished builds older than the live build are left over from interrupted
+// generations and are deleted too
+func Prune(generatedPath string, keep int, inUse ...string) error {
+	current, err := Current(generatedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read live build: %w", err)
+	}
+
+	buildsPath := filepath.Join(generatedPath, constants.BuildsPath)
+	entries, err := os.ReadDir(buildsPath)
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	// newest first, os.ReadDir sorts by name
+	kept := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		id := entries[i].Name()
+		if !entries[i].IsDir() {
+			continue
+		}
+
+		_, err := Load(generatedPath, id)
+		finished := err == nil
+		if id == current || slices.Contains(inUse, id) || (finished && kept < keep) {
+			kept++
+			continue
+		}
+		// builds newer than the live build may still be generating
+		if !finished && id > current {
+			continue
+		}
+
+		buildLogger.Info("Deleting old build %s", id)
+		if err := os.RemoveAll(filepath.Join(buildsPath, id)); err != nil {
+			return fmt.Errorf("failed to delete build %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Load reads the manifest of the finished build id
+func Load(generatedPath string, id string) (*Build, error) {
+	if id == "" || id == "." || id == ".." || filepath.Base(id) != id {
+		return nil, fmt.Errorf("%w: %s", ErrBuildNotFound, id)
+	}
+
+	buildPath := filepath.Join(generatedPath, constants.BuildsPath, id)
+	manifest, err := os.ReadFile(filepath.Clean(filepath.Join(buildPath, constants.BuildManifestPath)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBuildNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var build Build
+	if err := json.Unmarshal(manifest, &build); err != nil {
+		return nil, fmt.Errorf("failed to read manifest of build %s: %w", id, err)
+	}
+	build.Path = buildPath
+	return &build, nil
+}
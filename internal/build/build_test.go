@@ -0,0 +1,115 @@
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+)
+
+func TestActivatePruneRollback(t *testing.T) {
+	generatedPath := t.TempDir()
+
+	var ids []string
+	for range 4 {
+		build, err := New(generatedPath)
+		if err != nil {
+			t.Fatalf("New returned error: %v", err)
+		}
+		if err := build.Finish(1); err != nil {
+			t.Fatalf("Finish returned error: %v", err)
+		}
+		if err := Activate(generatedPath, build.ID); err != nil {
+			t.Fatalf("Activate returned error: %v", err)
+		}
+		ids = append(ids, build.ID)
+	}
+
+	// an unfinished build, ex. a generation in progress
+	pending, err := New(generatedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Activate(generatedPath, pending.ID); !errors.Is(err, ErrBuildNotFound) {
+		t.Errorf("activating an unfinished build returned %v, want ErrBuildNotFound", err)
+	}
+
+	if current, err := Current(generatedPath); err != nil || current != ids[3] {
+		t.Fatalf("Current = %s, %v, want %s", current, err, ids[3])
+	}
+
+	if err := Prune(generatedPath, 2); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	builds, err := List(generatedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 2 || builds[0].ID != ids[3] || builds[1].ID != ids[2] {
+		t.Fatalf("builds after prune = %+v, want %s and %s", builds, ids[3], ids[2])
+	}
+	if _, err := os.Stat(pending.Path); err != nil {
+		t.Errorf("prune deleted the pending build: %v", err)
+	}
+
+	// the build of the previous snapshot is kept even beyond keep
+	if err := Prune(generatedPath, 1, ids[2]); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if builds, _ := List(generatedPath); len(builds) != 2 {
+		t.Fatalf("builds after prune with a build in use = %+v, want %s and %s", builds, ids[3], ids[2])
+	}
+
+	if err := Rollback(generatedPath, ids[2]); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if current, _ := Current(generatedPath); current != ids[2] {
+		t.Errorf("Current after rollback = %s, want %s", current, ids[2])
+	}
+	if err := Rollback(generatedPath, ids[0]); !errors.Is(err, ErrBuildNotFound) {
+		t.Errorf("rolling back to a pruned build returned %v, want ErrBuildNotFound", err)
+	}
+	if err := Rollback(generatedPath, "../"+ids[2]); !errors.Is(err, ErrBuildNotFound) {
+		t.Errorf("rolling back to a path returned %v, want ErrBuildNotFound", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	buildPath := t.TempDir()
+	serverConfig := &config.ServerConfig{HTMLCompilationMode: constants.HTMLCompilationModeStatic}
+
+	if err := Validate(buildPath, serverConfig); err == nil {
+		t.Fatal("expected an error for an empty build")
+	}
+
+	write := func(name string, content string) {
+		t.Helper()
+		path := filepath.Join(buildPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(constants.SiteMapPath, `[{"path": "blog/post"}]`)
+	write("templates/layout.html", "")
+	write("templates/error.html", "")
+
+	if err := Validate(buildPath, serverConfig); err == nil {
+		t.Fatal("expected an error for a page without HTML")
+	}
+
+	write("html/blog/post.html", "<h1>Post</h1>")
+	if err := Validate(buildPath, serverConfig); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+
+	write(constants.SiteMapPath, `[{"path": "blog/post", "source_path": "blog/post.md"}, {"path": "blog/post", "source_path": "blog/Post.md"}]`)
+	if err := Validate(buildPath, serverConfig); err == nil {
+		t.Fatal("expected an error for pages with the same path")
+	}
+}
@@ -0,0 +1,212 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultDebounce is the quiet period after the last trigger before a build starts
+const DefaultDebounce = 500 * time.Millisecond
+
+// maxSuperseded is how many builds in a row can be cancelled by later
+// triggers. The next build runs to completion, so the site is updated even
+// when triggers arrive faster than a build takes
+const maxSuperseded = 3
+
+// Steps selects the parts of a build that are generated, the others are
+// copied from the live build
+type Steps struct {
+	HTML       bool
+	Assets     bool
+	UserStatic bool
+	Templates  bool
+	// the site map, redirect stubs and navigation
+	SiteMap bool
+}
+
+// AllSteps generates every part of a build
+var AllSteps = Steps{HTML: true, Assets: true, UserStatic: true, Templates: true, SiteMap: true}
+
+func (s Steps) merge(other Steps) Steps {
+	return Steps{
+		HTML:       s.HTML || other.HTML,
+		Assets:     s.Assets || other.Assets,
+		UserStatic: s.UserStatic || other.UserStatic,
+		Templates:  s.Templates || other.Templates,
+		SiteMap:    s.SiteMap || other.SiteMap,
+	}
+}
+
+// Request asks the coordinator for a build
+type Request struct {
+	// the name of the trigger, ex. Generation Cron, used in the logs
+	Caller string
+	// Full requests a full generation: configs, git sync and every step.
+	// Otherwise only Steps are generated from the live build
+	Full  bool
+	Steps Steps
+}
+
+// merge combines two requests into one build covering both
+func (r Request) merge(other Request) Request {
+	return Request{
+		Caller: other.Caller,
+		Full:   r.Full || other.Full,
+		Steps:  r.Steps.merge(other.Steps),
+	}
+}
+
+// Coordinator runs one build at a time. Triggers are debounced, and triggers
+// received during a build cancel it and are coalesced into a single follow-up
+// build, whose result would supersede it anyway. After maxSuperseded cancelled
+// builds in a row, the follow-up waits for the running build instead
+type Coordinator struct {
+	run      func(ctx context.Context, request Request) error
+	debounce time.Duration
+
+	// held while a build or an operation of Do runs
+	buildMu sync.Mutex
+
+	mu sync.Mutex
+	// the request waiting for the debounce period or the running build
+	pending *Request
+	timer   *time.Timer
+	// the debounce period of pending ended while a build was running
+	due     bool
+	running bool
+	cancel  context.CancelFunc
+	// builds cancelled in a row by later triggers
+	superseded int
+	closed     bool
+	// closed when no build is running or pending
+	idle chan struct{}
+}
+
+// NewCoordinator returns a Coordinator building with run. run must return
+// ctx.Err() when its context is cancelled
+func NewCoordinator(run func(ctx context.Context, request Request) error, debounce time.Duration) *Coordinator {
+	idle := make(chan struct{})
+	close(idle)
+	return &Coordinator{run: run, debounce: debounce, idle: idle}
+}
+
+// Trigger requests a build, which starts after the debounce period
+func (c *Coordinator) Trigger(request Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+
+	if c.pending != nil {
+		request = c.pending.merge(request)
+	} else if !c.running {
+		c.idle = make(chan struct{})
+	}
+	c.pending = &request
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.debounce, c.start)
+	} else {
+		c.timer.Reset(c.debounce)
+	}
+}
+
+// Wait blocks until no build is running or pending
+func (c *Coordinator) Wait() {
+	c.mu.Lock()
+	idle := c.idle
+	c.mu.Unlock()
+	<-idle
+}
+
+// Do runs fn once the running build, if any, returned. Builds started
+// meanwhile wait for fn, ex. a rollback must not be overwritten by a build
+// that started before it
+func (c *Coordinator) Do(fn func() error) error {
+	c.buildMu.Lock()
+	defer c.buildMu.Unlock()
+	return fn()
+}
+
+// Close cancels the running build and drops pending requests
+func (c *Coordinator) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if c.running {
+		c.cancel()
+	} else if c.pending != nil {
+		close(c.idle)
+	}
+	c.pending = nil
+}
+
+// start runs the pending request once its debounce period ended
+func (c *Coordinator) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil || c.closed {
+		return
+	}
+
+	if c.running {
+		// the follow-up starts once the running build returns, which is
+		// superseded unless too many builds in a row were
+		c.due = true
+		if c.superseded < maxSuperseded {
+			c.cancel()
+		}
+		return
+	}
+
+	request := *c.pending
+	c.pending = nil
+	c.due = false
+	ctx, cancel := context.WithCancel(context.Background())
+	c.running = true
+	c.cancel = cancel
+
+	go c.build(ctx, request)
+}
+
+func (c *Coordinator) build(ctx context.Context, request Request) {
+	c.buildMu.Lock()
+	err := c.run(ctx, request)
+	c.buildMu.Unlock()
+	cancelled := errors.Is(err, context.Canceled) && ctx.Err() != nil
+	if cancelled {
+		buildLogger.Info("Build requested by %s was superseded and cancelled", request.Caller)
+	}
+
+	c.mu.Lock()
+	c.cancel()
+	c.running = false
+	c.cancel = nil
+	if cancelled {
+		c.superseded++
+	} else {
+		c.superseded = 0
+	}
+
+	// the follow-up also does the work of a cancelled build
+	if cancelled && c.pending != nil {
+		merged := request.merge(*c.pending)
+		c.pending = &merged
+	}
+	if c.pending == nil {
+		close(c.idle)
+	}
+	// otherwise the follow-up is still debouncing and its timer starts it
+	due := c.due
+	c.mu.Unlock()
+
+	if due {
+		c.start()
+	}
+}
@@ -0,0 +1,129 @@
+package build
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoordinatorDebouncesTriggers(t *testing.T) {
+	var mu sync.Mutex
+	var requests []Request
+	coordinator := NewCoordinator(func(ctx context.Context, request Request) error {
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+		return nil
+	}, 20*time.Millisecond)
+
+	coordinator.Trigger(Request{Caller: "Watch", Steps: Steps{Templates: true}})
+	coordinator.Trigger(Request{Caller: "Watch", Steps: Steps{Assets: true}})
+	coordinator.Trigger(Request{Caller: "Git Webhook", Full: true})
+	coordinator.Wait()
+
+	if len(requests) != 1 {
+		t.Fatalf("ran %d builds, want a single build for the burst", len(requests))
+	}
+	want := Request{Caller: "Git Webhook", Full: true, Steps: Steps{Templates: true, Assets: true}}
+	if requests[0] != want {
+		t.Errorf("request = %+v, want %+v", requests[0], want)
+	}
+}
+
+func TestCoordinatorCancelsSupersededBuild(t *testing.T) {
+	started := make(chan Request, 10)
+	var mu sync.Mutex
+	var completed []Request
+	running := 0
+	maxRunning := 0
+
+	coordinator := NewCoordinator(func(ctx context.Context, request Request) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		started <- request
+		if request.Caller == "Slow" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		mu.Lock()
+		completed = append(completed, request)
+		mu.Unlock()
+		return nil
+	}, 10*time.Millisecond)
+
+	coordinator.Trigger(Request{Caller: "Slow", Full: true})
+	<-started
+
+	// both triggers arrive during the slow build and are coalesced into one follow-up
+	coordinator.Trigger(Request{Caller: "Watch", Steps: Steps{HTML: true}})
+	coordinator.Trigger(Request{Caller: "SIGHUP", Steps: Steps{SiteMap: true}})
+	coordinator.Wait()
+
+	if len(completed) != 1 {
+		t.Fatalf("completed %d builds, want 1 follow-up build", len(completed))
+	}
+	// the follow-up also covers the cancelled full build
+	want := Request{Caller: "SIGHUP", Full: true, Steps: Steps{HTML: true, SiteMap: true}}
+	if completed[0] != want {
+		t.Errorf("follow-up = %+v, want %+v", completed[0], want)
+	}
+	if maxRunning != 1 {
+		t.Errorf("%d builds ran at the same time, want 1", maxRunning)
+	}
+}
+
+func TestCoordinatorFinishesBuildsUnderContinuousTriggers(t *testing.T) {
+	completed := make(chan Request, 100)
+	coordinator := NewCoordinator(func(ctx context.Context, request Request) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+		completed <- request
+		return nil
+	}, 5*time.Millisecond)
+	defer coordinator.Close()
+
+	// triggers keep arriving at intervals shorter than a build
+	deadline := time.After(2 * time.Second)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		coordinator.Trigger(Request{Caller: "Watch", Steps: Steps{HTML: true}})
+		select {
+		case <-completed:
+			return
+		case <-deadline:
+			t.Fatal("no build completed while the triggers kept arriving")
+		case <-ticker.C:
+		}
+	}
+}
+
+func TestCoordinatorClose(t *testing.T) {
+	ran := false
+	coordinator := NewCoordinator(func(ctx context.Context, request Request) error {
+		ran = true
+		return nil
+	}, time.Hour)
+
+	coordinator.Trigger(Request{Caller: "Generation Cron", Full: true})
+	coordinator.Close()
+	coordinator.Wait()
+	coordinator.Trigger(Request{Caller: "Generation Cron", Full: true})
+	coordinator.Wait()
+
+	if ran {
+		t.Error("a closed coordinator ran a build")
+	}
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	"slices"
+	"sync"
+)
+
+// Failures keeps the most recent failed builds. Their directories are
+// deleted, so they are only known to the process that ran them
+type Failures struct {
+	mu     sync.Mutex
+	builds []Build
+	size   int
+}
+
+// NewFailures returns a Failures keeping the last size failed builds
+func NewFailures(size int) *Failures {
+	return &Failures{size: size}
+}
+
+// Add records a failed build
+func (f *Failures) Add(build Build) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.builds = append(f.builds, build)
+	if len(f.builds) > f.size {
+		f.builds = f.builds[len(f.builds)-f.size:]
+	}
+}
+
+// List returns the failed builds, newest first
+func (f *Failures) List() []Build {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	builds := slices.Clone(f.builds)
+	slices.Reverse(builds)
+	return builds
+}
@@ -0,0 +1,15 @@
+package build
+
+import "testing"
+
+func TestFailuresKeepsNewest(t *testing.T) {
+	failures := NewFailures(2)
+	for _, id := range []string{"a", "b", "c"} {
+		failures.Add(Build{ID: id})
+	}
+
+	builds := failures.List()
+	if len(builds) != 2 || builds[0].ID != "c" || builds[1].ID != "b" {
+		t.Errorf("List() = %v, want builds c and b", builds)
+	}
+}
@@ -0,0 +1,305 @@
+// Package compression negotiates the gzip and brotli compression of the
+// responses. Text responses are compressed on the fly, static files are served
+// from their precompressed .br and .gz siblings when they have them
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// content codings, in order of preference
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+var encodings = []string{Brotli, Gzip}
+
+// extensions of the precompressed siblings of the files by content coding
+var siblingExtensions = map[string]string{
+	Brotli: ".br",
+	Gzip:   ".gz",
+}
+
+// compressibleTypes are the media types compressed: HTML, CSS, JS, SVG, JSON and XML
+var compressibleTypes = []string{
+	"text/html",
+	"text/css",
+	"text/javascript",
+	"application/javascript",
+	"image/svg+xml",
+	"application/json",
+	"application/xml",
+	"text/xml",
+}
+
+// compressibleExtensions are the extensions of the files precompressed by Precompress
+var compressibleExtensions = []string{".html", ".css", ".js", ".mjs", ".svg", ".json", ".xml"}
+
+// responses smaller than this are not worth compressing
+const minSize = 1024
+
+// the writers are reset for each response, compressing on the fly uses the
+// default levels, which are much faster than the best ones of Precompress
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		writer, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return writer
+	}}
+	brotliWriters = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}}
+)
+
+// Accepted returns the content codings accepted by an Accept-Encoding header,
+// most preferred first. Brotli is preferred over gzip at the same quality
+func Accepted(acceptEncoding string) []string {
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if coding == "*" {
+			wildcard = quality
+		} else if slices.Contains(encodings, coding) {
+			qualities[coding] = quality
+		}
+	}
+
+	var accepted []string
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > 0 {
+			qualities[encoding] = quality
+			accepted = append(accepted, encoding)
+		}
+	}
+	slices.SortStableFunc(accepted, func(a, b string) int {
+		switch {
+		case qualities[a] > qualities[b]:
+			return -1
+		case qualities[a] < qualities[b]:
+			return 1
+		}
+		return 0
+	})
+	return accepted
+}
+
+// IsCompressibleType reports whether responses of a Content-Type are compressed
+func IsCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(compressibleTypes, mediaType)
+}
+
+// isCompressibleFile reports whether a file is precompressed, by its extension
+func isCompressibleFile(path string) bool {
+	return slices.Contains(compressibleExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// Middleware compresses the HTML, CSS, JS, SVG, JSON and XML responses of next
+// with the preferred content coding of the client. Responses already encoded,
+// ex. precompressed files, are written as is. The ETags of the compressed
+// responses are those of next with the content coding appended, next sees the
+// ETags of its own responses in If-None-Match
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		if accepted := Accepted(r.Header.Get("Accept-Encoding")); len(accepted) > 0 {
+			rw.encoding = accepted[0]
+		}
+
+		if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && rw.encoding != "" {
+			if decoded := decodedETags(ifNoneMatch, rw.encoding); decoded != ifNoneMatch {
+				r = r.Clone(r.Context())
+				r.Header.Set("If-None-Match", decoded)
+				rw.encodedValidator = true
+			}
+		}
+
+		defer rw.close()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// responseWriter compresses the response once its headers show it is compressible
+type responseWriter struct {
+	http.ResponseWriter
+	// preferred content coding of the client, empty when it accepts none
+	encoding string
+	// whether If-None-Match held an ETag of a response compressed with encoding
+	encodedValidator bool
+	encoder          io.WriteCloser
+	decided          bool
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if !rw.decided {
+		rw.decide(status, nil)
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if !rw.decided {
+		rw.decide(http.StatusOK, p)
+	}
+	if rw.encoder != nil {
+		return rw.encoder.Write(p)
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush flushes the compressed data written so far, ex. of streamed responses
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, ex. to
+// set the deadlines of the reload events of the watch mode
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// decide starts compressing the response when it is compressible, given its
+// status and first bytes
+func (rw *responseWriter) decide(status int, p []byte) {
+	rw.decided = true
+	header := rw.Header()
+
+	// the copy of the client that is still current is the compressed one
+	if status == http.StatusNotModified {
+		if rw.encodedValidator {
+			if etag := header.Get("ETag"); etag != "" {
+				header.Set("ETag", EncodedETag(etag, rw.encoding))
+			}
+			addVary(header)
+		}
+		return
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" && p != nil {
+		// sniffed from the uncompressed bytes, net/http would sniff the compressed ones
+		contentType = http.DetectContentType(p)
+		header.Set("Content-Type", contentType)
+	}
+	if !IsCompressibleType(contentType) {
+		return
+	}
+	addVary(header)
+
+	if rw.encoding == "" ||
+		header.Get("Content-Encoding") != "" ||
+		header.Get("Content-Range") != "" ||
+		status < http.StatusOK ||
+		status == http.StatusNoContent ||
+		status == http.StatusPartialContent {
+		return
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < minSize {
+		return
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", rw.encoding)
+	// the compressed representation has its own entity tag
+	if etag := header.Get("ETag"); etag != "" {
+		header.Set("ETag", EncodedETag(etag, rw.encoding))
+	}
+
+	switch rw.encoding {
+	case Brotli:
+		writer := brotliWriters.Get().(*brotli.Writer)
+		writer.Reset(rw.ResponseWriter)
+		rw.encoder = writer
+	case Gzip:
+		writer := gzipWriters.Get().(*gzip.Writer)
+		writer.Reset(rw.ResponseWriter)
+		rw.encoder = writer
+	}
+}
+
+// close flushes the compressed response and returns its writer to the pool
+func (rw *responseWriter) close() {
+	if rw.encoder == nil {
+		return
+	}
+	_ = rw.encoder.Close()
+	switch writer := rw.encoder.(type) {
+	case *brotli.Writer:
+		writer.Reset(io.Discard)
+		brotliWriters.Put(writer)
+	case *gzip.Writer:
+		writer.Reset(io.Discard)
+		gzipWriters.Put(writer)
+	}
+	rw.encoder = nil
+}
+
+// EncodedETag returns the entity tag of the representation of a response
+// encoded with a content coding, ex. "abc" becomes "abc-br"
+func EncodedETag(etag string, encoding string) string {
+	if !strings.HasSuffix(etag, `"`) || len(etag) < 2 {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+// decodedETags returns an If-None-Match header with the ETags of responses
+// compressed with encoding replaced by those of the uncompressed responses
+func decodedETags(ifNoneMatch string, encoding string) string {
+	suffix := "-" + encoding + `"`
+	if !strings.Contains(ifNoneMatch, suffix) {
+		return ifNoneMatch
+	}
+
+	etags := strings.Split(ifNoneMatch, ",")
+	for i, etag := range etags {
+		etag = strings.TrimSpace(etag)
+		if decoded, ok := strings.CutSuffix(etag, suffix); ok {
+			etag = decoded + `"`
+		}
+		etags[i] = etag
+	}
+	return strings.Join(etags, ", ")
+}
+
+// addVary adds Accept-Encoding to the Vary header, once
+func addVary(header http.Header) {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	header.Add("Vary", "Accept-Encoding")
+}
@@ -0,0 +1,242 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestAccepted(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           []string
+	}{
+		{"", nil},
+		{"gzip", []string{Gzip}},
+		{"gzip, deflate, br", []string{Brotli, Gzip}},
+		{"br;q=0.5, gzip", []string{Gzip, Brotli}},
+		{"br;q=0, gzip", []string{Gzip}},
+		{"*", []string{Brotli, Gzip}},
+		{"*;q=0.1, gzip;q=0", []string{Brotli}},
+		{"identity", nil},
+		{"GZIP;q=1.0", []string{Gzip}},
+		{"br;q=invalid, gzip", []string{Gzip}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := Accepted(tt.acceptEncoding); !slices.Equal(got, tt.want) {
+				t.Errorf("Accepted(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case Brotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case Gzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("invalid gzip body: %v", err)
+		}
+		reader = gzipReader
+	default:
+		return string(body)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("invalid %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestMiddleware(t *testing.T) {
+	page := "<!DOCTYPE html><html><body>" + strings.Repeat("<p>compressible</p>", 200) + "</body></html>"
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		contentLength  string
+		body           string
+		wantEncoding   string
+		wantVary       bool
+	}{
+		{"brotli preferred", "gzip, br", "text/html; charset=utf-8", "", page, Brotli, true},
+		{"gzip", "gzip", "application/json", "", page, Gzip, true},
+		{"sniffed HTML", "gzip", "", "", page, Gzip, true},
+		{"not accepted", "", "text/html", "", page, "", true},
+		{"image", "gzip", "image/png", "", page, "", false},
+		{"small response", "gzip", "text/css", "10", "body {}\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.contentLength != "" {
+					w.Header().Set("Content-Length", tt.contentLength)
+				}
+				_, _ = io.WriteString(w, tt.body)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary") == "Accept-Encoding"; got != tt.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding: %v", rec.Header().Get("Vary"), tt.wantVary)
+			}
+			if got := decode(t, tt.wantEncoding, rec.Body.Bytes()); got != tt.body {
+				t.Errorf("decoded body differs from the response of the handler")
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeepsEncodedResponses(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		w.Header().Set("Content-Encoding", Gzip)
+		w.Header().Set("Vary", "Accept-Encoding")
+		_, _ = w.Write(bytes.Repeat([]byte("precompressed"), 200))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != Gzip {
+		t.Errorf("Content-Encoding = %q, want the one of the handler", got)
+	}
+	if got := rec.Header().Values("Vary"); len(got) != 1 {
+		t.Errorf("Vary = %v, want Accept-Encoding once", got)
+	}
+	if rec.Body.Len() != len("precompressed")*200 {
+		t.Errorf("encoded response was modified")
+	}
+}
+
+func TestMiddlewareRevalidatesEncodedETags(t *testing.T) {
+	page := strings.Repeat("<p>compressible</p>", 200)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		if slices.Contains(strings.Split(r.Header.Get("If-None-Match"), ", "), `"abc"`) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, page)
+	}))
+
+	tests := []struct {
+		acceptEncoding string
+		ifNoneMatch    string
+		wantStatus     int
+		wantETag       string
+	}{
+		{"br", "", http.StatusOK, `"abc-br"`},
+		{"br", `"abc-br"`, http.StatusNotModified, `"abc-br"`},
+		{"gzip", `"other", "abc-gzip"`, http.StatusNotModified, `"abc-gzip"`},
+		{"", `"abc"`, http.StatusNotModified, `"abc"`},
+		{"gzip", `"abc-br"`, http.StatusOK, `"abc-gzip"`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		req.Header.Set("If-None-Match", tt.ifNoneMatch)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%q with If-None-Match %s: status = %d, want %d", tt.acceptEncoding, tt.ifNoneMatch, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("ETag"); got != tt.wantETag {
+			t.Errorf("%q with If-None-Match %s: ETag = %s, want %s", tt.acceptEncoding, tt.ifNoneMatch, got, tt.wantETag)
+		}
+	}
+}
+
+func TestPrecompressAndFileServer(t *testing.T) {
+	dir := t.TempDir()
+	script := strings.Repeat("console.log('precompressed');\n", 100)
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.css"), []byte("body {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "image.png"), bytes.Repeat([]byte{0}, 4096), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Precompress(context.Background(), dir); err != nil {
+		t.Fatalf("Precompress() error = %v", err)
+	}
+
+	original, err := os.Stat(filepath.Join(dir, "app.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sibling := range []string{"app.js.br", "app.js.gz"} {
+		info, err := os.Stat(filepath.Join(dir, sibling))
+		if err != nil {
+			t.Fatalf("sibling %s not written: %v", sibling, err)
+		}
+		if !info.ModTime().Equal(original.ModTime()) {
+			t.Errorf("sibling %s modified at %v, want the time of its file %v", sibling, info.ModTime(), original.ModTime())
+		}
+	}
+	for _, skipped := range []string{"small.css.gz", "small.css.br", "image.png.gz", "image.png.br"} {
+		if _, err := os.Stat(filepath.Join(dir, skipped)); !os.IsNotExist(err) {
+			t.Errorf("sibling %s written, want it skipped", skipped)
+		}
+	}
+
+	server := FileServer(dir)
+	for _, tt := range []struct{ acceptEncoding, wantEncoding string }{
+		{"br, gzip", Brotli},
+		{"gzip", Gzip},
+		{"", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.acceptEncoding, got, tt.wantEncoding)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/javascript") {
+			t.Errorf("Content-Type = %q, want the type of app.js", got)
+		}
+		if got := decode(t, tt.wantEncoding, rec.Body.Bytes()); got != script {
+			t.Errorf("Accept-Encoding %q: decoded body differs from app.js", tt.acceptEncoding)
+		}
+	}
+}
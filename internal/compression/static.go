@@ -0,0 +1,168 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/andybalholm/brotli"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/routines"
+	"golang.org/x/sync/errgroup"
+)
+
+// compressionLogger logs the messages of the compression service
+var compressionLogger = logger.Service("compression")
+
+// FileServer serves the files of root like http.FileServer. A compressible
+// file is served from its precompressed .br or .gz sibling, written by
+// Precompress, when the client accepts its content coding
+func FileServer(root string) http.Handler {
+	files := http.FileServer(http.Dir(root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if !isCompressibleFile(name) {
+			files.ServeHTTP(w, r)
+			return
+		}
+		addVary(w.Header())
+
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		for _, encoding := range Accepted(r.Header.Get("Accept-Encoding")) {
+			if servePrecompressed(w, r, filePath, encoding) {
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// servePrecompressed serves the sibling of filePath precompressed with
+// encoding, it reports whether the file has one
+func servePrecompressed(w http.ResponseWriter, r *http.Request, filePath string, encoding string) bool {
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		return false
+	}
+
+	file, err := os.Open(filepath.Clean(filePath + siblingExtensions[encoding]))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, r, filepath.Base(filePath), info.ModTime(), file)
+	return true
+}
+
+// Precompress writes .br and .gz siblings of the HTML, CSS, JS, SVG, JSON and
+// XML files of the directories, with the best compression. Files too small to
+// gain from it are skipped, as are siblings larger than their file
+func Precompress(ctx context.Context, dirs ...string) error {
+	var paths []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.Type().IsRegular() && isCompressibleFile(path) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list the files of %s: %w", dir, err)
+		}
+	}
+	if len(paths) == 0 {
+		compressionLogger.Ctx(ctx).Debug("No files to precompress")
+		return nil
+	}
+
+	maxWorkers := routines.CalculateMaxWorkers(len(paths))
+	compressionLogger.Ctx(ctx).Info("Precompressing %d files with %d concurrent workers", len(paths), maxWorkers)
+
+	// a cancelled build stops the remaining workers
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxWorkers)
+
+	for i, path := range paths {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := precompressFile(path); err != nil {
+				compressionLogger.Ctx(ctx).Error("[Worker %d] Failed to precompress %s: %v", i, path, err)
+				return fmt.Errorf("failed to precompress %s: %w", path, err)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	compressionLogger.Ctx(ctx).Info("Successfully precompressed all files")
+	return nil
+}
+
+// precompressFile writes the .br and .gz siblings of a file, with its
+// modification time so all its representations have the same Last-Modified
+func precompressFile(path string) error {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	if len(content) < minSize {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	for _, encoding := range encodings {
+		var compressed bytes.Buffer
+		var encoder io.WriteCloser
+		switch encoding {
+		case Brotli:
+			encoder = brotli.NewWriterLevel(&compressed, brotli.BestCompression)
+		case Gzip:
+			encoder, _ = gzip.NewWriterLevel(&compressed, gzip.BestCompression)
+		}
+		if _, err := encoder.Write(content); err != nil {
+			return err
+		}
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+		if compressed.Len() >= len(content) {
+			continue
+		}
+
+		siblingPath := path + siblingExtensions[encoding]
+		if err := os.WriteFile(siblingPath, compressed.Bytes(), 0600); err != nil {
+			return err
+		}
+		if err := os.Chtimes(siblingPath, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
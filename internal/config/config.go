@@ -12,6 +12,9 @@ import (
 	"github.com/jaysongiroux/mdserve/internal/logger"
 )
 
+// configLogger logs the messages of the config service
+var configLogger = logger.Service("config")
+
 type ConfigType string
 
 const (
@@ -33,20 +36,40 @@ const (
 	ENV_VAR_GIT_USERNAME = "GIT_USERNAME"
 	// PAT is preferred over password
 	ENV_VAR_GIT_PASSWORD = "GIT_PASSWORD"
+	// secret of the git push webhook, used when git_webhook_secret is not set
+	ENV_VAR_GIT_WEBHOOK_SECRET = "GIT_WEBHOOK_SECRET"
+	// token of the admin API, used when admin_token is not set
+	ENV_VAR_ADMIN_TOKEN = "ADMIN_TOKEN"
 )
 
 func getConfigPath(defaultPath string, envVariable string) string {
 	envValue := os.Getenv(envVariable)
 	if envValue != "" {
-		logger.Debug("Using config path from environment variable %s: %s", envVariable, envValue)
+		configLogger.Debug("Using config path from environment variable %s: %s", envVariable, envValue)
 		return envValue
 	}
-	logger.Debug("Using default config path: %s", defaultPath)
+	configLogger.Debug("Using default config path: %s", defaultPath)
 	return defaultPath
 }
 
+// LocalConfigPaths returns the paths of the server and site configs that are
+// read from local files, remote configs are skipped
+func LocalConfigPaths() []string {
+	var paths []string
+	for _, configPath := range []string{
+		getConfigPath(constants.ServerConfigPath, ENV_VAR_MD_SERVER_CONFIG_PATH),
+		getConfigPath(constants.SiteConfigPath, ENV_VAR_MD_SITE_CONFIG_PATH),
+	} {
+		if strings.HasSuffix(configPath, ".git") || strings.HasPrefix(configPath, "http") {
+			continue
+		}
+		paths = append(paths, configPath)
+	}
+	return paths
+}
+
 func getRemoteConfigContent(configPath string) (string, error) {
-	logger.Debug("Fetching remote config from: %s", configPath)
+	configLogger.Debug("Fetching remote config from: %s", configPath)
 	response, err := http.Get(filepath.Clean(configPath))
 	if err != nil {
 		return "", err
@@ -54,7 +77,7 @@ func getRemoteConfigContent(configPath string) (string, error) {
 	defer func() {
 		err := response.Body.Close()
 		if err != nil {
-			logger.Error("Failed to close response body: %v", err)
+			configLogger.Error("Failed to close response body: %v", err)
 		}
 	}()
 
@@ -67,7 +90,7 @@ func getRemoteConfigContent(configPath string) (string, error) {
 		return "", fmt.Errorf("failed to fetch config: %d", response.StatusCode)
 	}
 
-	logger.Debug("Successfully fetched remote config (%d bytes)", len(body))
+	configLogger.Debug("Successfully fetched remote config (%d bytes)", len(body))
 	return string(body), nil
 }
 
@@ -82,12 +105,12 @@ func GetConfigContent(configType ConfigType) (string, error) {
 		return "", fmt.Errorf("invalid config type: %s", configType)
 	}
 
-	logger.Debug("Loading %s config from: %s", configType, configPath)
+	configLogger.Debug("Loading %s config from: %s", configType, configPath)
 	if strings.HasSuffix(configPath, ".git") {
 		branch := os.Getenv(ENV_VAR_MD_CONFIG_BRANCH)
 		configLocation := os.Getenv(ENV_VAR_MD_CONFIG_LOCATION)
 
-		logger.Debug("Fetching remote config from: %s", configPath)
+		configLogger.Debug("Fetching remote config from: %s", configPath)
 		configFileName := ""
 		switch configType {
 		case ConfigTypeServer:
@@ -116,6 +139,6 @@ func GetConfigContent(configType ConfigType) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read local config: %w", err)
 	}
-	logger.Debug("Successfully read local config: %s", configPath)
+	configLogger.Debug("Successfully read local config: %s", configPath)
 	return string(configContent), nil
 }
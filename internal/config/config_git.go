@@ -11,7 +11,6 @@ import (
 	"github.com/go-git/go-git/v6/plumbing"
 	"github.com/go-git/go-git/v6/storage/memory"
 	"github.com/jaysongiroux/mdserve/internal/auth"
-	"github.com/jaysongiroux/mdserve/internal/logger"
 )
 
 func GetFileFromRepo(
@@ -40,28 +39,28 @@ func GetFileFromRepo(
 		Auth:          auth.CreateGitBasicAuth(&username, &password),
 	})
 	if err != nil {
-		logger.Error("Failed to clone git repository: %v", err)
+		configLogger.Error("Failed to clone git repository: %v", err)
 		return "", fmt.Errorf("failed to clone git repository: %w", err)
 	}
 
 	content, err := fs.Open(filepath.Join(path, configFileName))
 	if err != nil {
-		logger.Error("Failed to open file: %v. %v", filepath.Join(path, configFileName), err)
+		configLogger.Error("Failed to open file: %v. %v", filepath.Join(path, configFileName), err)
 		return "", fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() {
 		err := content.Close()
 		if err != nil {
-			logger.Error("Failed to close file: %v", err)
+			configLogger.Error("Failed to close file: %v", err)
 		}
 	}()
 
 	contentBytes, err := io.ReadAll(content)
 	if err != nil {
-		logger.Error("Failed to read file: %v", err)
+		configLogger.Error("Failed to read file: %v", err)
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	logger.Debug("Successfully read file: %s", path)
+	configLogger.Debug("Successfully read file: %s", path)
 	return string(contentBytes), nil
 }
@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/jaysongiroux/mdserve/internal/constants"
 	"github.com/jaysongiroux/mdserve/internal/logger"
@@ -32,6 +36,18 @@ type ServerConfig struct {
 	CacheStaticMaxAge                   int                           `yaml:"cache_static_max_age"`
 	SyncTemplates                       bool                          `yaml:"sync_templates"`
 	SyncAssets                          bool                          `yaml:"sync_assets"`
+	ContentDatesSource                  constants.ContentDatesSource  `yaml:"content_dates_source"`
+	BuildsToKeep                        int                           `yaml:"builds_to_keep"`
+	GitWebhookSecret                    string                        `yaml:"git_webhook_secret"`
+	AdminToken                          string                        `yaml:"admin_token"`
+	MetricsEnabled                      bool                          `yaml:"metrics_enabled"`
+	TracingEnabled                      bool                          `yaml:"tracing_enabled"`
+	TracingEndpoint                     string                        `yaml:"tracing_endpoint"`
+	TrustedProxies                      []string                      `yaml:"trusted_proxies"`
+	LogEncoding                         string                        `yaml:"log_encoding"`
+	LogOutputs                          []string                      `yaml:"log_outputs"`
+	LogRotation                         logger.Rotation               `yaml:"log_rotation"`
+	LogLevels                           map[string]logger.LogLevel    `yaml:"log_levels"`
 }
 
 func LoadServerConfig() (*ServerConfig, error) {
@@ -60,6 +76,27 @@ func (c *ServerConfig) Validate() error {
 		return err
 	}
 
+	if err := c.validateContentDatesSource(); err != nil {
+		return err
+	}
+
+	if err := c.validateBuildsToKeep(); err != nil {
+		return err
+	}
+
+	if _, err := c.TrustedProxyPrefixes(); err != nil {
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	if err := c.validateLogging(); err != nil {
+		return err
+	}
+
+	if err := c.validateTracingEndpoint(); err != nil {
+		return err
+	}
+
 	// Validate git remote content configuration if enabled
 	if c.GitRemoteContentURL != "" {
 		if err := c.validateGitRemoteFields(); err != nil {
@@ -67,6 +104,14 @@ func (c *ServerConfig) Validate() error {
 		}
 	}
 
+	if c.GitWebhookSecret == "" {
+		c.GitWebhookSecret = os.Getenv(ENV_VAR_GIT_WEBHOOK_SECRET)
+	}
+
+	if c.AdminToken == "" {
+		c.AdminToken = os.Getenv(ENV_VAR_ADMIN_TOKEN)
+	}
+
 	return nil
 }
 
@@ -74,12 +119,12 @@ func (c *ServerConfig) Validate() error {
 func (c *ServerConfig) validateMutuallyExclusiveOptions() error {
 	if c.Demo && c.GitRemoteContentURL != "" {
 		err := fmt.Errorf("demo mode and git remote content URL cannot be enabled at the same time")
-		logger.Error(err.Error())
+		configLogger.Error(err.Error())
 		return err
 	}
 
 	if c.Demo {
-		logger.Warn(
+		configLogger.Warn(
 			"Demo mode is enabled. This will copy the README to the content folder as index.md",
 		)
 	}
@@ -87,6 +132,123 @@ func (c *ServerConfig) validateMutuallyExclusiveOptions() error {
 	return nil
 }
 
+// validateContentDatesSource ensures the dates source is a known value, defaulting to the filesystem
+func (c *ServerConfig) validateContentDatesSource() error {
+	switch c.ContentDatesSource {
+	case "":
+		c.ContentDatesSource = constants.ContentDatesSourceFilesystem
+	case constants.ContentDatesSourceFilesystem, constants.ContentDatesSourceGit:
+	default:
+		err := fmt.Errorf(
+			"invalid content_dates_source %q, must be %q or %q",
+			c.ContentDatesSource,
+			constants.ContentDatesSourceFilesystem,
+			constants.ContentDatesSourceGit,
+		)
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// validateBuildsToKeep ensures at least the live build is kept, defaulting to DefaultBuildsToKeep
+func (c *ServerConfig) validateBuildsToKeep() error {
+	if c.BuildsToKeep == 0 {
+		c.BuildsToKeep = constants.DefaultBuildsToKeep
+	}
+
+	if c.BuildsToKeep < 1 {
+		err := fmt.Errorf("invalid builds_to_keep %d, must be at least 1", c.BuildsToKeep)
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// validateLogging ensures the log encoding is known, defaulting to console
+// lines written to stdout
+func (c *ServerConfig) validateLogging() error {
+	switch c.LogEncoding {
+	case "":
+		c.LogEncoding = logger.EncodingConsole
+	case logger.EncodingConsole, logger.EncodingJSON:
+	default:
+		err := fmt.Errorf(
+			"invalid log_encoding %q, must be %q or %q",
+			c.LogEncoding,
+			logger.EncodingConsole,
+			logger.EncodingJSON,
+		)
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	if len(c.LogOutputs) == 0 {
+		c.LogOutputs = []string{logger.OutputStdout}
+	}
+
+	if c.LogRotation.MaxSizeMB < 0 || c.LogRotation.MaxAgeDays < 0 || c.LogRotation.MaxBackups < 0 {
+		err := fmt.Errorf("invalid log_rotation, the sizes, ages and backups cannot be negative")
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// validateTracingEndpoint ensures the OTLP endpoint, when set, is an HTTP URL
+func (c *ServerConfig) validateTracingEndpoint() error {
+	if c.TracingEndpoint == "" {
+		return nil
+	}
+
+	endpoint, err := url.Parse(c.TracingEndpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		err := fmt.Errorf("invalid tracing_endpoint %q, must be an http or https URL", c.TracingEndpoint)
+		configLogger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// LoggingConfig returns the configuration of the loggers
+func (c *ServerConfig) LoggingConfig() logger.Config {
+	return logger.Config{
+		Level:         c.LogLevel,
+		Encoding:      c.LogEncoding,
+		Outputs:       c.LogOutputs,
+		Rotation:      c.LogRotation,
+		ServiceLevels: c.LogLevels,
+	}
+}
+
+// TrustedProxyPrefixes parses the trusted proxies, single addresses are
+// returned as prefixes of their full length
+func (c *ServerConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted_proxies entry %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted_proxies entry %q: %w", proxy, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
 // validateGitRemoteFields ensures at least one directory is configured and branch is always required
 func (c *ServerConfig) validateGitRemoteFields() error {
 	// Branch is always required
@@ -94,7 +256,7 @@ func (c *ServerConfig) validateGitRemoteFields() error {
 		err := fmt.Errorf(
 			"git_remote_content_branch is required when git remote content URL is provided",
 		)
-		logger.Error(err.Error())
+		configLogger.Error(err.Error())
 		return err
 	}
 
@@ -107,13 +269,28 @@ func (c *ServerConfig) validateGitRemoteFields() error {
 		err := fmt.Errorf(
 			"at least one directory must be configured (git_remote_content_directory, git_remote_content_assets_directory, or git_remote_content_user_static_directory) when git remote content URL is provided",
 		)
-		logger.Error(err.Error())
+		configLogger.Error(err.Error())
 		return err
 	}
 
 	return nil
 }
 
+func (c *ServerConfig) UsesGitContentDates() bool {
+	return c.ContentDatesSource == constants.ContentDatesSourceGit
+}
+
+// GitWebhookEnabled reports whether the git push webhook is served, which
+// requires git remote content and a webhook secret
+func (c *ServerConfig) GitWebhookEnabled() bool {
+	return c.GitRemoteContentURL != "" && c.GitWebhookSecret != ""
+}
+
+// AdminEnabled reports whether the admin API is served, which requires an admin token
+func (c *ServerConfig) AdminEnabled() bool {
+	return c.AdminToken != ""
+}
+
 func (c *ServerConfig) HasGitRemoteContentDirectory() bool {
 	return c.GitRemoteContentDirectory != ""
 }
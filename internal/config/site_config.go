@@ -1,14 +1,54 @@
 package config
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
 	"go.yaml.in/yaml/v3"
 )
 
 // define config structs
 type SiteConfig struct {
-	Navbar []NavbarItem `yaml:"navbar"`
-	Footer Footer       `yaml:"footer"`
-	Site   Site         `yaml:"site"`
+	Navbar    []NavbarItem `yaml:"navbar"`
+	Footer    Footer       `yaml:"footer"`
+	Site      Site         `yaml:"site"`
+	Authors   []Author     `yaml:"authors"`
+	Redirects []Redirect   `yaml:"redirects"`
+}
+
+// Redirect sends requests for a path that no longer has a page elsewhere
+type Redirect struct {
+	// path to redirect (ex. /old-post), or a regex when Regex is set
+	From string `yaml:"from"`
+	// target URL, may reference regex groups ($1) when Regex is set. Not used for 410
+	To string `yaml:"to"`
+	// treat From as an (unanchored) regex matched against the request path
+	Regex bool `yaml:"regex"`
+	// 301 (default), 302 or 410
+	Status int `yaml:"status"`
+	// Pattern is From compiled when Regex is set, by the site config validation
+	Pattern *regexp.Regexp `yaml:"-"`
+}
+
+// Author is an author profile pages can reference by id or name
+// in their author/authors metadata
+type Author struct {
+	ID     string `yaml:"id"`
+	Name   string `yaml:"name"`
+	Bio    string `yaml:"bio"`
+	Avatar string `yaml:"avatar"`
+	Links  []Link `yaml:"links"`
+}
+
+// URL returns the profile page of the author, empty for authors not defined in the site config
+func (a Author) URL() string {
+	if a.ID == "" {
+		return ""
+	}
+	return "/authors/" + url.PathEscape(a.ID) + "/"
 }
 
 type Footer struct {
@@ -28,6 +68,66 @@ type Site struct {
 	Author                    string        `yaml:"author"`
 	Description               string        `yaml:"description"`
 	AllowSearchEngineIndexing bool          `yaml:"allow_search_engine_indexing"`
+	// canonical base URL of the site (ex. https://example.com), used for absolute
+	// links in the sitemap. Falls back to the request host when empty
+	BaseURL string  `yaml:"base_url"`
+	Robots  Robots  `yaml:"robots"`
+	LLMsTxt LLMsTxt `yaml:"llms_txt"`
+	Related Related `yaml:"related"`
+	Archive Archive `yaml:"archive"`
+	// layout from the layout_templates directory used for /authors/<id>/ pages
+	AuthorLayout string     `yaml:"author_layout"`
+	Navigation   Navigation `yaml:"navigation"`
+	URLs         URLs       `yaml:"urls"`
+}
+
+// URLs configures how page URLs are derived from content file paths
+type URLs struct {
+	// preserve (default), lowercase or dash
+	Slugify SlugifyPolicy `yaml:"slugify"`
+	// ignore (default), always or never
+	TrailingSlash TrailingSlashPolicy `yaml:"trailing_slash"`
+}
+
+// Navigation configures the navigation tree generated from the content directory
+type Navigation struct {
+	Enabled bool `yaml:"enabled"`
+	// append the generated top level sections to the navbar as dropdowns
+	MergeNavbar bool `yaml:"merge_navbar"`
+}
+
+// Related configures the related pages computed for every page at build time
+type Related struct {
+	// number of related pages per page, 0 disables related pages
+	Count int `yaml:"count"`
+}
+
+// Robots configures the generated robots.txt
+type Robots struct {
+	// per user-agent rules. A "*" group is always emitted
+	Rules []RobotsRule `yaml:"rules"`
+	// path patterns disallowed for every user-agent (ex. /drafts/*)
+	Disallow []string `yaml:"disallow"`
+}
+
+type RobotsRule struct {
+	UserAgent string   `yaml:"user_agent"`
+	Allow     []string `yaml:"allow"`
+	Disallow  []string `yaml:"disallow"`
+}
+
+// LLMsTxt configures the generated /llms.txt index for LLM crawlers
+type LLMsTxt struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Archive configures the /archive/, /archive/<year>/ and /archive/<year>/<month>/ pages
+type Archive struct {
+	Enabled bool `yaml:"enabled"`
+	// regex used to filter the site map, only matching pages are archived
+	Filter string `yaml:"filter"`
+	// layout template from the layout_templates directory
+	Layout string `yaml:"layout"`
 }
 
 type Layout struct {
@@ -63,6 +163,31 @@ const (
 	SortDirectionDesc SortDirection = "desc"
 )
 
+// SlugifyPolicy is how file and directory names are turned into URL segments
+type SlugifyPolicy string
+
+const (
+	// SlugifyPreserve keeps names as they are, replacing spaces with _
+	SlugifyPreserve SlugifyPolicy = "preserve"
+	// SlugifyLowercase is SlugifyPreserve in lowercase
+	SlugifyLowercase SlugifyPolicy = "lowercase"
+	// SlugifyDash lowercases names and replaces every run of characters that
+	// are not letters or digits with a single -
+	SlugifyDash SlugifyPolicy = "dash"
+)
+
+// TrailingSlashPolicy is whether page URLs end with a slash
+type TrailingSlashPolicy string
+
+const (
+	// TrailingSlashIgnore serves pages with and without a trailing slash
+	TrailingSlashIgnore TrailingSlashPolicy = "ignore"
+	// TrailingSlashAlways redirects page URLs without a trailing slash
+	TrailingSlashAlways TrailingSlashPolicy = "always"
+	// TrailingSlashNever redirects page URLs with a trailing slash
+	TrailingSlashNever TrailingSlashPolicy = "never"
+)
+
 func LoadSiteConfig() (*SiteConfig, error) {
 	configContent, err := GetConfigContent(ConfigTypeSite)
 	if err != nil {
@@ -75,5 +200,144 @@ func LoadSiteConfig() (*SiteConfig, error) {
 		return nil, err
 	}
 
+	if err := siteConfig.validateRedirects(); err != nil {
+		return nil, fmt.Errorf("site config validation failed: %w", err)
+	}
+
+	if err := siteConfig.Site.URLs.validate(); err != nil {
+		return nil, fmt.Errorf("site config validation failed: %w", err)
+	}
+
+	if err := siteConfig.validateAuthors(); err != nil {
+		return nil, fmt.Errorf("site config validation failed: %w", err)
+	}
+
 	return &siteConfig, nil
 }
+
+// validateAuthors checks the author ids are set and unique, ids are matched
+// case-insensitively. Authors without a name default to their id
+func (c *SiteConfig) validateAuthors() error {
+	seen := map[string]bool{}
+	for i := range c.Authors {
+		author := &c.Authors[i]
+		if strings.TrimSpace(author.ID) == "" {
+			return fmt.Errorf("author %d: id is required", i)
+		}
+
+		key := strings.ToLower(author.ID)
+		if seen[key] {
+			return fmt.Errorf("author %s: duplicate id", author.ID)
+		}
+		seen[key] = true
+
+		if author.Name == "" {
+			author.Name = author.ID
+		}
+	}
+
+	return nil
+}
+
+// validateRedirects checks the redirect statuses, defaulting them to 301, and
+// compiles the patterns of regex redirects
+func (c *SiteConfig) validateRedirects() error {
+	for i := range c.Redirects {
+		redirect := &c.Redirects[i]
+		if redirect.From == "" {
+			return fmt.Errorf("redirect %d: from is required", i)
+		}
+
+		switch redirect.Status {
+		case 0:
+			redirect.Status = http.StatusMovedPermanently
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusGone:
+		default:
+			return fmt.Errorf("redirect %s: invalid status %d, must be 301, 302 or 410", redirect.From, redirect.Status)
+		}
+
+		if redirect.Status != http.StatusGone && redirect.To == "" {
+			return fmt.Errorf("redirect %s: to is required for status %d", redirect.From, redirect.Status)
+		}
+
+		if redirect.Regex {
+			pattern, err := regexp.Compile(redirect.From)
+			if err != nil {
+				return fmt.Errorf("redirect %s: invalid regex: %w", redirect.From, err)
+			}
+			redirect.Pattern = pattern
+		}
+	}
+
+	return nil
+}
+
+// validate checks the URL policies, defaulting them to preserve and ignore
+func (u *URLs) validate() error {
+	switch u.Slugify {
+	case "":
+		u.Slugify = SlugifyPreserve
+	case SlugifyPreserve, SlugifyLowercase, SlugifyDash:
+	default:
+		return fmt.Errorf("invalid urls.slugify %q, must be preserve, lowercase or dash", u.Slugify)
+	}
+
+	switch u.TrailingSlash {
+	case "":
+		u.TrailingSlash = TrailingSlashIgnore
+	case TrailingSlashIgnore, TrailingSlashAlways, TrailingSlashNever:
+	default:
+		return fmt.Errorf("invalid urls.trailing_slash %q, must be ignore, always or never", u.TrailingSlash)
+	}
+
+	return nil
+}
+
+// GetAuthor returns the author with the given id, matched case-insensitively
+func (c *SiteConfig) GetAuthor(id string) (Author, bool) {
+	for _, author := range c.Authors {
+		if strings.EqualFold(author.ID, id) {
+			return author, true
+		}
+	}
+	return Author{}, false
+}
+
+// ResolveAuthors maps author ids or display names to author profiles. Names
+// that don't match a configured author resolve to an author with only a name
+func (c *SiteConfig) ResolveAuthors(names []string) []Author {
+	var authors []Author
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		author, ok := c.GetAuthor(name)
+		if !ok {
+			for _, candidate := range c.Authors {
+				if strings.EqualFold(candidate.Name, name) {
+					author, ok = candidate, true
+					break
+				}
+			}
+		}
+		if !ok {
+			author = Author{Name: name}
+		}
+		if author.Name == "" {
+			author.Name = author.ID
+		}
+
+		key := strings.ToLower(author.ID + "/" + author.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		authors = append(authors, author)
+	}
+
+	return authors
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetAuthor(t *testing.T) {
+	siteConfig := &SiteConfig{Authors: []Author{
+		{ID: "jdoe", Name: "Jane Doe"},
+		{ID: "bsmith", Name: "Bob Smith"},
+	}}
+
+	tests := []struct {
+		id       string
+		wantName string
+		wantOK   bool
+	}{
+		{"jdoe", "Jane Doe", true},
+		{"JDoe", "Jane Doe", true},
+		{"bsmith", "Bob Smith", true},
+		// display names are only matched by ResolveAuthors
+		{"Jane Doe", "", false},
+		{"unknown", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			author, ok := siteConfig.GetAuthor(tt.id)
+			if ok != tt.wantOK || author.Name != tt.wantName {
+				t.Errorf("GetAuthor(%q) = %q, %v, want %q, %v", tt.id, author.Name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResolveAuthors(t *testing.T) {
+	siteConfig := &SiteConfig{Authors: []Author{
+		{ID: "jdoe", Name: "Jane Doe", Bio: "Writes about Go"},
+		{ID: "bsmith", Name: "Bob Smith"},
+		{ID: "anon"},
+		// an id matches before a name, even the name of another author
+		{ID: "Bob Smith", Name: "Robert"},
+	}}
+
+	tests := []struct {
+		name  string
+		names []string
+		// id/name of the resolved authors
+		want string
+	}{
+		{"By id", []string{"jdoe"}, "jdoe/Jane Doe"},
+		{"By id case insensitive", []string{"JDOE"}, "jdoe/Jane Doe"},
+		{"By name", []string{"jane doe"}, "jdoe/Jane Doe"},
+		{"Id before name", []string{"Bob Smith"}, "Bob Smith/Robert"},
+		{"Author without a name uses the id", []string{"anon"}, "anon/anon"},
+		{"Unknown name", []string{"Guest Writer"}, "/Guest Writer"},
+		{"Whitespace and empty names", []string{"  jdoe ", "", "   "}, "jdoe/Jane Doe"},
+		{"Duplicates by id and name", []string{"jdoe", "Jane Doe", "JDOE"}, "jdoe/Jane Doe"},
+		{"Duplicate unknown names", []string{"Guest Writer", "guest writer"}, "/Guest Writer"},
+		{"Order is kept", []string{"bsmith", "Guest Writer", "jdoe"}, "bsmith/Bob Smith,/Guest Writer,jdoe/Jane Doe"},
+		{"No names", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, author := range siteConfig.ResolveAuthors(tt.names) {
+				got = append(got, fmt.Sprintf("%s/%s", author.ID, author.Name))
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("ResolveAuthors(%q) = %q, want %q", tt.names, strings.Join(got, ","), tt.want)
+			}
+		})
+	}
+
+	authors := siteConfig.ResolveAuthors([]string{"Jane Doe"})
+	if len(authors) != 1 || authors[0].Bio != "Writes about Go" || authors[0].URL() != "/authors/jdoe/" {
+		t.Errorf("ResolveAuthors() by name = %+v, want the configured profile of jdoe", authors)
+	}
+	authors = siteConfig.ResolveAuthors([]string{"Guest Writer"})
+	if len(authors) != 1 || authors[0].URL() != "" {
+		t.Errorf("ResolveAuthors() of an unknown name = %+v, want an author without a profile page", authors)
+	}
+}
+
+func TestValidateAuthors(t *testing.T) {
+	tests := []struct {
+		name    string
+		authors []Author
+		wantErr bool
+		// names of the authors after validation
+		wantNames string
+	}{
+		{"Valid", []Author{{ID: "jdoe", Name: "Jane Doe"}, {ID: "bsmith", Name: "Bob Smith"}}, false, "Jane Doe,Bob Smith"},
+		{"Name defaults to the id", []Author{{ID: "anon"}}, false, "anon"},
+		{"No authors", nil, false, ""},
+		{"Missing id", []Author{{Name: "Jane Doe"}}, true, ""},
+		{"Blank id", []Author{{ID: "  ", Name: "Jane Doe"}}, true, ""},
+		{"Duplicate id", []Author{{ID: "jdoe"}, {ID: "JDoe", Name: "Other"}}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			siteConfig := &SiteConfig{Authors: tt.authors}
+			err := siteConfig.validateAuthors()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateAuthors() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var names []string
+			for _, author := range siteConfig.Authors {
+				names = append(names, author.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.wantNames {
+				t.Errorf("names = %q, want %q", got, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestValidateRedirects(t *testing.T) {
+	siteConfig := &SiteConfig{Redirects: []Redirect{
+		{From: "/old", To: "/new"},
+		{From: "^/archive/(\\d+)/$", To: "/blog?year=$1", Regex: true, Status: 302},
+	}}
+	if err := siteConfig.validateRedirects(); err != nil {
+		t.Fatalf("validateRedirects() error = %v", err)
+	}
+	if siteConfig.Redirects[0].Status != 301 || siteConfig.Redirects[0].Pattern != nil {
+		t.Errorf("exact redirect = %+v, want status 301 without a pattern", siteConfig.Redirects[0])
+	}
+	if pattern := siteConfig.Redirects[1].Pattern; pattern == nil || !pattern.MatchString("/archive/2024/") {
+		t.Errorf("regex redirect pattern = %v, want the compiled from", pattern)
+	}
+
+	invalid := &SiteConfig{Redirects: []Redirect{{From: "(", To: "/", Regex: true}}}
+	if err := invalid.validateRedirects(); err == nil {
+		t.Error("validateRedirects() of an invalid regex succeeded")
+	}
+}
@@ -0,0 +1,8 @@
+package constants
+
+type ContentDatesSource string
+
+const (
+	ContentDatesSourceFilesystem ContentDatesSource = "filesystem"
+	ContentDatesSourceGit        ContentDatesSource = "git"
+)
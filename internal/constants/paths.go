@@ -12,10 +12,24 @@ const (
 	GeneratedPath             = ".generated"
 	HTMLFilesPath             = "html"
 	SiteMapPath               = "sitemap.json"
+	NavigationPath            = "navigation.json"
+	BuildsPath                = "builds"
+	LiveBuildPath             = "current"
+	BuildManifestPath         = "build.json"
 	GeneratedAssetsPath       = "assets"
 	GitRemoteContentDirectory = ".git-remote-content"
 )
 
+const (
+	// number of builds kept under <generated_path>/builds for rollbacks
+	DefaultBuildsToKeep = 3
+)
+
+const (
+	// per-directory file listing the order of its pages in the navigation tree
+	NavOrderFileName = ".order"
+)
+
 const (
 	// copies the readme to the content/index.md file
 	DemoReadmeURL = "https://raw.githubusercontent.com/jaysongiroux/MDServe/refs/heads/master/README.md"
@@ -12,6 +12,9 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// filesLogger logs the messages of the files service
+var filesLogger = logger.Service("files")
+
 func GetFileModifiedDate(path string) (time.Time, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -67,13 +70,13 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 	}
 
 	// Create destination directory with source permissions
-	logger.Debug(
+	filesLogger.Debug(
 		"Creating destination directory %s with source permissions %v",
 		destinationPath,
 		sourceInfo.Mode(),
 	)
 	if err := os.MkdirAll(destinationPath, sourceInfo.Mode()); err != nil {
-		logger.Error(
+		filesLogger.Error(
 			"Failed to create destination directory %s with source permissions %v: %v",
 			destinationPath,
 			sourceInfo.Mode(),
@@ -85,16 +88,16 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 	destinationPaths := []DestinationPath{}
 
 	err = filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-		logger.Debug("Walking source path %s", path)
+		filesLogger.Debug("Walking source path %s", path)
 		if err != nil {
-			logger.Error("Failed to walk source path %s: %v", sourcePath, err)
+			filesLogger.Error("Failed to walk source path %s: %v", sourcePath, err)
 			return err
 		}
 
 		// Calculate relative path from source
 		relPath, err := filepath.Rel(sourcePath, path)
 		if err != nil {
-			logger.Error(
+			filesLogger.Error(
 				"Failed to get relative path from source path %s to path %s: %v",
 				sourcePath,
 				path,
@@ -113,7 +116,7 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 		return nil
 	})
 	if err != nil {
-		logger.Error("Failed to walk source path %s: %v", sourcePath, err)
+		filesLogger.Error("Failed to walk source path %s: %v", sourcePath, err)
 		return fmt.Errorf("failed to walk source path: %w", err)
 	}
 
@@ -123,17 +126,17 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 	for i, destPath := range destinationPaths {
 		g.Go(func() error {
 			// Handle directories
-			logger.Debug("[Worker %d] Handling directory: %s", i, destPath.destination)
+			filesLogger.Debug("[Worker %d] Handling directory: %s", i, destPath.destination)
 			info, err := os.Stat(destPath.path)
 			if err != nil {
-				logger.Error("[Worker %d] Failed to stat directory: %s: %v", i, destPath, err)
+				filesLogger.Error("[Worker %d] Failed to stat directory: %s: %v", i, destPath, err)
 				return fmt.Errorf("failed to stat directory: %w", err)
 			}
 
 			if info.IsDir() {
 				// Create directory with same permissions
 				if err := os.MkdirAll(destPath.destination, info.Mode()); err != nil {
-					logger.Error(
+					filesLogger.Error(
 						"Failed to create directory %s with source permissions %v: %v",
 						destPath,
 						info.Mode(),
@@ -149,7 +152,7 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 			}
 
 			// Handle files
-			logger.Debug(
+			filesLogger.Debug(
 				"[Worker %d] Copying file: %s to %s",
 				i,
 				destPath.path,
@@ -157,11 +160,11 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 			)
 			err = CopyFile(destPath.path, destPath.destination, true)
 			if err != nil {
-				logger.Error("[Worker %d] Failed to copy file: %s: %v", i, destPath.path, err)
+				filesLogger.Error("[Worker %d] Failed to copy file: %s: %v", i, destPath.path, err)
 				return fmt.Errorf("failed to copy file: %w", err)
 			}
 
-			logger.Debug(
+			filesLogger.Debug(
 				"[Worker %d] Copied file: %s to %s",
 				i,
 				destPath.path,
@@ -175,7 +178,7 @@ func RecursivelyCopyDirectory(sourcePath string, destinationPath string) error {
 		return fmt.Errorf("failed to recursively copy directory: %w", err)
 	}
 
-	logger.Info("Successfully recursively copied directory: %s", sourcePath)
+	filesLogger.Info("Successfully recursively copied directory: %s", sourcePath)
 	return nil
 }
 
@@ -221,7 +224,7 @@ func CopyFile(sourcePath, destinationPath string, overwrite bool) error {
 	defer func() {
 		err := src.Close()
 		if err != nil {
-			logger.Error("Failed to close source file: %v", err)
+			filesLogger.Error("Failed to close source file: %v", err)
 		}
 	}()
 
@@ -237,7 +240,7 @@ func CopyFile(sourcePath, destinationPath string, overwrite bool) error {
 	defer func() {
 		err := dst.Close()
 		if err != nil {
-			logger.Error("Failed to close destination file: %v", err)
+			filesLogger.Error("Failed to close destination file: %v", err)
 		}
 	}()
 
@@ -257,16 +260,16 @@ func CopyFile(sourcePath, destinationPath string, overwrite bool) error {
 func DeleteDirectoryContents(directoryPath string) error {
 	// Check if the directory exists
 	if _, err := os.Stat(directoryPath); os.IsNotExist(err) {
-		logger.Debug("Directory %s does not exist", directoryPath)
+		filesLogger.Debug("Directory %s does not exist", directoryPath)
 		return nil
 	}
 
-	logger.Debug("Deleting directory contents of %s", directoryPath)
+	filesLogger.Debug("Deleting directory contents of %s", directoryPath)
 
 	// Read all entries in the directory
 	entries, err := os.ReadDir(directoryPath)
 	if err != nil {
-		logger.Error("Failed to read directory %s: %v", directoryPath, err)
+		filesLogger.Error("Failed to read directory %s: %v", directoryPath, err)
 		return fmt.Errorf("failed to read directory: %w", err)
 	}
 
@@ -275,20 +278,20 @@ func DeleteDirectoryContents(directoryPath string) error {
 		entryPath := filepath.Join(directoryPath, entry.Name())
 
 		if entry.IsDir() {
-			logger.Debug("Deleting subdirectory %s", entryPath)
+			filesLogger.Debug("Deleting subdirectory %s", entryPath)
 			if err := os.RemoveAll(entryPath); err != nil {
-				logger.Error("Failed to delete subdirectory %s: %v", entryPath, err)
+				filesLogger.Error("Failed to delete subdirectory %s: %v", entryPath, err)
 				return fmt.Errorf("failed to delete subdirectory %s: %w", entryPath, err)
 			}
 		} else {
-			logger.Debug("Deleting file %s", entryPath)
+			filesLogger.Debug("Deleting file %s", entryPath)
 			if err := os.Remove(entryPath); err != nil {
-				logger.Error("Failed to delete file %s: %v", entryPath, err)
+				filesLogger.Error("Failed to delete file %s: %v", entryPath, err)
 				return fmt.Errorf("failed to delete file %s: %w", entryPath, err)
 			}
 		}
 	}
 
-	logger.Debug("Successfully deleted directory contents of %s", directoryPath)
+	filesLogger.Debug("Successfully deleted directory contents of %s", directoryPath)
 	return nil
 }
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,9 @@ import (
 	"github.com/jaysongiroux/mdserve/internal/logger"
 )
 
+// gitLogger logs the messages of the git service
+var gitLogger = logger.Service("git")
+
 // Errors
 const (
 	ErrAlreadyUpToDate = "already up-to-date"
@@ -22,97 +26,121 @@ const (
 	ErrOutOfSync       = "out of sync"
 )
 
-func fetchGitRemoteContent(url string, destinationPath string, branch string) error {
+// cloneDepth returns the clone depth to use, full history is needed when
+// page dates are taken from git
+func cloneDepth(serverConfig *config.ServerConfig) int {
+	if serverConfig.UsesGitContentDates() {
+		return 0
+	}
+	return 1
+}
+
+func fetchGitRemoteContent(ctx context.Context, url string, destinationPath string, branch string, depth int) error {
 	// clone the remote repository to the destination path
-	logger.Debug("Cloning git remote content from %s to %s", url, destinationPath)
+	gitLogger.Ctx(ctx).Debug("Cloning git remote content from %s to %s (depth %d)", url, destinationPath, depth)
 	username := os.Getenv(config.ENV_VAR_GIT_USERNAME)
 	password := os.Getenv(config.ENV_VAR_GIT_PASSWORD)
-	repo, err := git.PlainClone(destinationPath, &git.CloneOptions{
+	repo, err := git.PlainCloneContext(ctx, destinationPath, &git.CloneOptions{
 		URL:          url,
-		Depth:        1,
+		Depth:        depth,
 		SingleBranch: true,
 		Auth:         auth.CreateGitBasicAuth(&username, &password),
 	})
 
 	if err != nil {
-		logger.Error("Failed to clone git remote content from %s to %s: %v", url, destinationPath, err)
+		gitLogger.Ctx(ctx).Error("Failed to clone git remote content from %s to %s: %v", url, destinationPath, err)
 		return err
 	}
 
 	branchRef, err := repo.Branch(branch)
 	if err != nil {
-		logger.Error("Failed to get branch %s: %v", branch, err)
+		gitLogger.Ctx(ctx).Error("Failed to get branch %s: %v", branch, err)
 		return err
 	}
 	if branchRef == nil {
-		logger.Error("Branch %s does not exist", branch)
+		gitLogger.Ctx(ctx).Error("Branch %s does not exist", branch)
 		return fmt.Errorf("branch %s does not exist", branch)
 	}
 
 	worktree, err := repo.Worktree()
 	if err != nil {
-		logger.Error("Failed to get worktree: %v", err)
+		gitLogger.Ctx(ctx).Error("Failed to get worktree: %v", err)
 		return err
 	}
 
-	logger.Debug("Checking out branch %s", branch)
+	gitLogger.Ctx(ctx).Debug("Checking out branch %s", branch)
 	branchRefName := plumbing.NewBranchReferenceName(branch)
 	err = worktree.Checkout(&git.CheckoutOptions{
 		Branch: branchRefName,
 		Force:  true,
 	})
 	if err != nil {
-		logger.Error("Failed to checkout branch %s: %v", branch, err)
+		gitLogger.Ctx(ctx).Error("Failed to checkout branch %s: %v", branch, err)
 		return err
 	}
 
-	logger.Debug("Successfully checked out branch %s", branch)
+	gitLogger.Ctx(ctx).Debug("Successfully checked out branch %s", branch)
 
 	return nil
 }
 
 func openGitRepository(directory string) (*git.Repository, error) {
-	logger.Debug("Opening git repository at %s", directory)
+	gitLogger.Debug("Opening git repository at %s", directory)
 	repo, err := git.PlainOpen(directory)
 	if err != nil {
 		return nil, err
 	}
-	logger.Debug("Successfully opened git repository at %s", directory)
+	gitLogger.Debug("Successfully opened git repository at %s", directory)
 	return repo, nil
 }
 
+// isShallowRepository reports whether the repository was cloned without its full history
+func isShallowRepository(directory string) (bool, error) {
+	repo, err := openGitRepository(directory)
+	if err != nil {
+		return false, err
+	}
+
+	shallowCommits, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, err
+	}
+
+	return len(shallowCommits) > 0, nil
+}
+
 func createGitRemoteContentDirectory() (string, error) {
 	// create a directory to clone the git remote content to if it doesnt exist
-	logger.Debug("Creating git remote content directory at %s", constants.GitRemoteContentDirectory)
+	gitLogger.Debug("Creating git remote content directory at %s", constants.GitRemoteContentDirectory)
 	err := os.MkdirAll(constants.GitRemoteContentDirectory, 0750)
 	if err != nil {
 		return "", err
 	}
 
-	logger.Debug("Successfully created git remote content directory at %s", constants.GitRemoteContentDirectory)
+	gitLogger.Debug("Successfully created git remote content directory at %s", constants.GitRemoteContentDirectory)
 	return constants.GitRemoteContentDirectory, nil
 }
 
-func pullLatestGitRemoteContent(branch string, directory string) error {
+func pullLatestGitRemoteContent(ctx context.Context, branch string, directory string) error {
 	username := os.Getenv(config.ENV_VAR_GIT_USERNAME)
 	password := os.Getenv(config.ENV_VAR_GIT_PASSWORD)
 
 	// open the repository
 	repo, err := openGitRepository(directory)
 	if err != nil {
-		logger.Warn("Failed to open git repository at %s: %v", directory, err)
+		gitLogger.Ctx(ctx).Warn("Failed to open git repository at %s: %v", directory, err)
 		return fmt.Errorf("git repository is %s: %w", ErrOutOfSync, err)
 	}
 
 	worktree, err := repo.Worktree()
 	if err != nil {
-		logger.Warn("Failed to get worktree: %v", err)
+		gitLogger.Ctx(ctx).Warn("Failed to get worktree: %v", err)
 		return fmt.Errorf("git repository is %s: %w", ErrOutOfSync, err)
 	}
 
-	logger.Debug("Pulling the latest changes from branch %s", branch)
+	gitLogger.Ctx(ctx).Debug("Pulling the latest changes from branch %s", branch)
 	branchRefName := plumbing.NewBranchReferenceName(branch)
-	err = worktree.Pull(&git.PullOptions{
+	err = worktree.PullContext(ctx, &git.PullOptions{
 		RemoteName:    "origin",
 		ReferenceName: branchRefName,
 		Force:         true,
@@ -121,25 +149,25 @@ func pullLatestGitRemoteContent(branch string, directory string) error {
 
 	if err != nil {
 		if strings.Contains(err.Error(), ErrAlreadyUpToDate) {
-			logger.Debug("Branch %s is already up-to-date", branch)
+			gitLogger.Ctx(ctx).Debug("Branch %s is already up-to-date", branch)
 		} else if strings.Contains(err.Error(), ErrObjectNotFound) {
 			// Object not found error typically occurs with shallow clones when
 			// the remote has been force-pushed or rebased, or when the repository
 			// is corrupted (common in Docker container restarts without volumes)
-			logger.Warn("Git objects not found for branch %s, repository needs to be re-cloned", branch)
+			gitLogger.Ctx(ctx).Warn("Git objects not found for branch %s, repository needs to be re-cloned", branch)
 			return fmt.Errorf("git repository is %s: %w", ErrOutOfSync, err)
 		} else if strings.Contains(err.Error(), "reference not found") ||
 			strings.Contains(err.Error(), "couldn't find remote ref") ||
 			strings.Contains(err.Error(), "repository does not exist") {
 			// These errors indicate repository corruption or invalid state
-			logger.Warn("Repository reference errors detected, repository needs to be re-cloned")
+			gitLogger.Ctx(ctx).Warn("Repository reference errors detected, repository needs to be re-cloned")
 			return fmt.Errorf("git repository is %s: %w", ErrOutOfSync, err)
 		} else {
-			logger.Error("Failed to pull down the latest changes from branch %s: %v", branch, err)
+			gitLogger.Ctx(ctx).Error("Failed to pull down the latest changes from branch %s: %v", branch, err)
 			return err
 		}
 	} else {
-		logger.Debug("Successfully pulled down the latest changes from branch %s", branch)
+		gitLogger.Ctx(ctx).Debug("Successfully pulled down the latest changes from branch %s", branch)
 	}
 
 	return nil
@@ -155,7 +183,7 @@ func isGitRemoteContentDirectoryAGitRepository() (bool, error) {
 	// This helps detect corrupted or incomplete repositories (common in Docker restarts)
 	worktree, err := repo.Worktree()
 	if err != nil {
-		logger.Debug("Repository exists but worktree is invalid: %v", err)
+		gitLogger.Debug("Repository exists but worktree is invalid: %v", err)
 		return false, nil
 	}
 
@@ -163,7 +191,7 @@ func isGitRemoteContentDirectoryAGitRepository() (bool, error) {
 	// This ensures the repository has been properly initialized with at least one commit
 	_, err = repo.Head()
 	if err != nil {
-		logger.Debug("Repository exists but HEAD reference is invalid: %v", err)
+		gitLogger.Debug("Repository exists but HEAD reference is invalid: %v", err)
 		return false, nil
 	}
 
@@ -171,7 +199,7 @@ func isGitRemoteContentDirectoryAGitRepository() (bool, error) {
 	// In ephemeral Docker containers, the directory might exist but be empty
 	status, err := worktree.Status()
 	if err != nil {
-		logger.Debug("Repository exists but status check failed: %v", err)
+		gitLogger.Debug("Repository exists but status check failed: %v", err)
 		return false, nil
 	}
 
@@ -179,11 +207,11 @@ func isGitRemoteContentDirectoryAGitRepository() (bool, error) {
 	// This is a critical file that should always exist in a valid git repository
 	gitConfigPath := filepath.Join(constants.GitRemoteContentDirectory, ".git", "config")
 	if _, err := os.Stat(gitConfigPath); os.IsNotExist(err) {
-		logger.Debug("Repository directory exists but .git/config is missing")
+		gitLogger.Debug("Repository directory exists but .git/config is missing")
 		return false, nil
 	}
 
-	logger.Debug("Repository validation successful, status has %d entries", len(status))
+	gitLogger.Debug("Repository validation successful, status has %d entries", len(status))
 	return true, nil
 }
 
@@ -193,50 +221,50 @@ func copyRemoteContentToLocalContent(remoteDirectory string, localDirectory stri
 	// performed again to be safe
 	exists, err := files.CheckIfDirectoryExists(localDirectory)
 	if err != nil {
-		logger.Error("Failed to check if local directory %s exists: %v", localDirectory, err)
+		gitLogger.Error("Failed to check if local directory %s exists: %v", localDirectory, err)
 		return err
 	}
 	if !exists {
-		logger.Error("Local directory %s does not exist", localDirectory)
+		gitLogger.Error("Local directory %s does not exist", localDirectory)
 		return fmt.Errorf("local directory %s does not exist", localDirectory)
 	}
 
 	// copy the contents from the remote directory to the local directory
-	logger.Debug("Copying contents from remote directory %s to local directory %s", remoteDirectory, localDirectory)
+	gitLogger.Debug("Copying contents from remote directory %s to local directory %s", remoteDirectory, localDirectory)
 	completeRemoteDirectory := filepath.Join(constants.GitRemoteContentDirectory, remoteDirectory)
 	err = files.RecursivelyCopyDirectory(completeRemoteDirectory, localDirectory)
 	if err != nil {
-		logger.Error("Failed to copy contents from remote directory %s to local directory %s: %v", remoteDirectory, localDirectory, err)
+		gitLogger.Error("Failed to copy contents from remote directory %s to local directory %s: %v", remoteDirectory, localDirectory, err)
 		return err
 	}
 
-	logger.Debug("Successfully copied the contents from the remote directory %s to the local directory %s", remoteDirectory, localDirectory)
+	gitLogger.Debug("Successfully copied the contents from the remote directory %s to the local directory %s", remoteDirectory, localDirectory)
 
 	return nil
 }
 
-func HandleGitRemoteContent(serverConfig *config.ServerConfig) error {
+func HandleGitRemoteContent(ctx context.Context, serverConfig *config.ServerConfig) error {
 	if serverConfig.GitRemoteContentURL == "" {
-		logger.Debug("No git remote content URL provided, using local content path")
+		gitLogger.Ctx(ctx).Debug("No git remote content URL provided, using local content path")
 		return nil
 	}
 
 	exists, err := files.CheckIfDirectoryExists(constants.GitRemoteContentDirectory)
 	if err != nil {
-		logger.Error("Failed to check if git remote content directory exists: %v", err)
+		gitLogger.Ctx(ctx).Error("Failed to check if git remote content directory exists: %v", err)
 		return err
 	}
 
 	//  create the directory
 	var directory string
 	if !exists {
-		logger.Debug("Git remote content directory does not exist, creating it")
+		gitLogger.Ctx(ctx).Debug("Git remote content directory does not exist, creating it")
 		directory, err = createGitRemoteContentDirectory()
 		if err != nil {
 			return err
 		}
 	} else {
-		logger.Debug("Git remote content directory exists, using it")
+		gitLogger.Ctx(ctx).Debug("Git remote content directory exists, using it")
 		directory = constants.GitRemoteContentDirectory
 	}
 
@@ -246,54 +274,77 @@ func HandleGitRemoteContent(serverConfig *config.ServerConfig) error {
 	if err != nil {
 		return err
 	}
+	// a shallow clone does not have the history needed for git page dates
+	if isGitRepository && serverConfig.UsesGitContentDates() {
+		shallow, err := isShallowRepository(directory)
+		if err != nil {
+			gitLogger.Ctx(ctx).Warn("Failed to check if git repository is shallow: %v", err)
+		}
+		if shallow || err != nil {
+			gitLogger.Ctx(ctx).Info("Git remote content is a shallow clone, re-cloning with full history for git page dates")
+			isGitRepository = false
+		}
+	}
 	// if it is not a git repository, clone the remote content
 	if !isGitRepository {
-		logger.Debug("Git remote content directory is not a valid git repository, preparing for fresh clone")
+		gitLogger.Ctx(ctx).Debug("Git remote content directory is not a valid git repository, preparing for fresh clone")
 
 		// Clean up any existing corrupted or partial data
 		// This is especially important in Docker containers where restarts might leave partial data
-		logger.Debug("Cleaning up directory before cloning")
+		gitLogger.Ctx(ctx).Debug("Cleaning up directory before cloning")
 		if removeErr := os.RemoveAll(directory); removeErr != nil {
-			logger.Error("Failed to clean up directory at %s: %v", directory, removeErr)
+			gitLogger.Ctx(ctx).Error("Failed to clean up directory at %s: %v", directory, removeErr)
 			return fmt.Errorf("failed to clean up directory: %w", removeErr)
 		}
 
 		// Recreate the directory
 		if mkdirErr := os.MkdirAll(directory, 0750); mkdirErr != nil {
-			logger.Error("Failed to recreate git remote content directory: %v", mkdirErr)
+			gitLogger.Ctx(ctx).Error("Failed to recreate git remote content directory: %v", mkdirErr)
 			return fmt.Errorf("failed to recreate directory: %w", mkdirErr)
 		}
 
-		logger.Debug("Cloning git remote content")
-		err = fetchGitRemoteContent(serverConfig.GitRemoteContentURL, directory, serverConfig.GitRemoteContentBranch)
+		gitLogger.Ctx(ctx).Debug("Cloning git remote content")
+		err = fetchGitRemoteContent(
+			ctx,
+			serverConfig.GitRemoteContentURL,
+			directory,
+			serverConfig.GitRemoteContentBranch,
+			cloneDepth(serverConfig),
+		)
 		if err != nil {
 			return err
 		}
 	} else {
-		logger.Debug("Git remote content directory is a git repository, pulling down the latest changes")
-		err = pullLatestGitRemoteContent(serverConfig.GitRemoteContentBranch, directory)
+		gitLogger.Ctx(ctx).Debug("Git remote content directory is a git repository, pulling down the latest changes")
+		err = pullLatestGitRemoteContent(ctx, serverConfig.GitRemoteContentBranch, directory)
 		if err != nil {
 			// If the repository is out of sync (e.g., "object not found" errors from shallow clones
 			// after force pushes), delete it and re-clone fresh
 			if strings.Contains(err.Error(), ErrOutOfSync) {
-				logger.Info("Repository is out of sync, deleting and re-cloning fresh")
+				gitLogger.Ctx(ctx).Info("Repository is out of sync, deleting and re-cloning fresh")
 				// Delete the corrupted repository
 				if removeErr := os.RemoveAll(directory); removeErr != nil {
-					logger.Error("Failed to remove corrupted git repository at %s: %v", directory, removeErr)
+					gitLogger.Ctx(ctx).Error("Failed to remove corrupted git repository at %s: %v", directory, removeErr)
 					return fmt.Errorf("failed to remove corrupted repository: %w", removeErr)
 				}
 				// Recreate the directory
 				if mkdirErr := os.MkdirAll(directory, 0750); mkdirErr != nil {
-					logger.Error("Failed to recreate git remote content directory: %v", mkdirErr)
+					gitLogger.Ctx(ctx).Error("Failed to recreate git remote content directory: %v", mkdirErr)
 					return fmt.Errorf("failed to recreate directory: %w", mkdirErr)
 				}
 				// Re-clone the repository
-				logger.Info("Re-cloning repository from %s", serverConfig.GitRemoteContentURL)
-				err = fetchGitRemoteContent(serverConfig.GitRemoteContentURL, directory, serverConfig.GitRemoteContentBranch)
+				gitLogger.Ctx(ctx).Info("Re-cloning repository from %s", serverConfig.GitRemoteContentURL)
+				err = fetchGitRemoteContent(
+					ctx,
+					serverConfig.GitRemoteContentURL,
+					directory,
+					serverConfig.GitRemoteContentBranch,
+					cloneDepth(serverConfig),
+				)
 				if err != nil {
 					return fmt.Errorf("failed to re-clone repository: %w", err)
 				}
-				logger.Info("Successfully re-cloned repository")
+				gitLogger.Ctx(ctx).Info("Successfully re-cloned repository")
 			} else {
 				return err
 			}
@@ -302,7 +353,7 @@ func HandleGitRemoteContent(serverConfig *config.ServerConfig) error {
 
 	err = syncGitRemoteDirectories(serverConfig)
 	if err != nil {
-		logger.Error("Failed to sync git remote directories: %v", err)
+		gitLogger.Ctx(ctx).Error("Failed to sync git remote directories: %v", err)
 		return err
 	}
 
@@ -340,7 +391,7 @@ func syncGitRemoteDirectories(serverConfig *config.ServerConfig) error {
 
 	for _, dir := range directories {
 		if !dir.hasDirectory {
-			logger.Debug("No git remote %s directory provided, using local %s path", dir.name, dir.name)
+			gitLogger.Debug("No git remote %s directory provided, using local %s path", dir.name, dir.name)
 			continue
 		}
 
@@ -353,25 +404,25 @@ func syncGitRemoteDirectories(serverConfig *config.ServerConfig) error {
 }
 
 func syncDirectory(remotePath, localPath, name string) error {
-	logger.Debug("Copying %s from remote directory %s to local directory %s", name, remotePath, localPath)
+	gitLogger.Debug("Copying %s from remote directory %s to local directory %s", name, remotePath, localPath)
 
 	// Delete existing contents
 	if err := files.DeleteDirectoryContents(localPath); err != nil {
 		return fmt.Errorf("failed to delete directory %s: %w", localPath, err)
 	}
-	logger.Debug("Successfully deleted the contents of the local directory %s", localPath)
+	gitLogger.Debug("Successfully deleted the contents of the local directory %s", localPath)
 
 	// Create local directory
 	if err := os.MkdirAll(localPath, 0750); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", localPath, err)
 	}
-	logger.Debug("Successfully created the local %s directory at %s", name, localPath)
+	gitLogger.Debug("Successfully created the local %s directory at %s", name, localPath)
 
 	// Copy contents
 	if err := copyRemoteContentToLocalContent(remotePath, localPath); err != nil {
 		return fmt.Errorf("failed to copy from %s to %s: %w", remotePath, localPath, err)
 	}
-	logger.Debug("Successfully copied the contents from the remote directory %s to the local directory %s", remotePath, localPath)
+	gitLogger.Debug("Successfully copied the contents from the remote directory %s to the local directory %s", remotePath, localPath)
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -14,30 +15,29 @@ import (
 	"github.com/go-git/go-git/v6/storage/memory"
 	"github.com/jaysongiroux/mdserve/internal/config"
 	"github.com/jaysongiroux/mdserve/internal/constants"
-	"github.com/jaysongiroux/mdserve/internal/logger"
 )
 
-func HandleSyncFromRepo(serverConfig *config.ServerConfig) error {
+func HandleSyncFromRepo(ctx context.Context, serverConfig *config.ServerConfig) error {
 	if !serverConfig.SyncAssets && !serverConfig.SyncTemplates {
-		logger.Debug("Syncing templates and assets is disabled, skipping")
+		gitLogger.Ctx(ctx).Debug("Syncing templates and assets is disabled, skipping")
 		return nil
 	}
 
-	fs, err := tempCloneRepo()
+	fs, err := tempCloneRepo(ctx)
 	if err != nil {
-		logger.Error("Failed to clone repo: %v", err)
+		gitLogger.Ctx(ctx).Error("Failed to clone repo: %v", err)
 		return fmt.Errorf("failed to clone repo: %w", err)
 	}
 
 	if serverConfig.SyncTemplates {
-		logger.Debug("Syncing templates is enabled, syncing templates")
+		gitLogger.Ctx(ctx).Debug("Syncing templates is enabled, syncing templates")
 		if err := syncTemplates(serverConfig, fs); err != nil {
 			return fmt.Errorf("failed to sync templates: %w", err)
 		}
 	}
 
 	if serverConfig.SyncAssets {
-		logger.Debug("Syncing assets is enabled, syncing assets")
+		gitLogger.Ctx(ctx).Debug("Syncing assets is enabled, syncing assets")
 		if err := syncAssets(serverConfig, fs); err != nil {
 			return fmt.Errorf("failed to sync assets: %w", err)
 		}
@@ -46,16 +46,16 @@ func HandleSyncFromRepo(serverConfig *config.ServerConfig) error {
 	return nil
 }
 
-func tempCloneRepo() (billy.Filesystem, error) {
+func tempCloneRepo(ctx context.Context) (billy.Filesystem, error) {
 	fs := memfs.New()
 
-	_, err := git.Clone(memory.NewStorage(), fs, &git.CloneOptions{
+	_, err := git.CloneContext(ctx, memory.NewStorage(), fs, &git.CloneOptions{
 		URL:          constants.RepoUrl,
 		SingleBranch: true,
 		Depth:        1,
 	})
 	if err != nil {
-		logger.Error("Failed to clone repo: %v", err)
+		gitLogger.Ctx(ctx).Error("Failed to clone repo: %v", err)
 		return nil, fmt.Errorf("failed to clone repo: %w", err)
 	}
 
@@ -63,12 +63,12 @@ func tempCloneRepo() (billy.Filesystem, error) {
 }
 
 func syncAssets(serverConfig *config.ServerConfig, fs billy.Filesystem) error {
-	logger.Info("Syncing assets from remote repository")
+	gitLogger.Info("Syncing assets from remote repository")
 	return syncMemFSFilesystemDirectory(fs, constants.RepoAssetsDirectory, serverConfig.AssetsPath)
 }
 
 func syncTemplates(serverConfig *config.ServerConfig, fs billy.Filesystem) error {
-	logger.Info("Syncing templates from remote repository")
+	gitLogger.Info("Syncing templates from remote repository")
 	return syncMemFSFilesystemDirectory(fs, constants.RepoTemplatesDirectory, serverConfig.TemplatesPath)
 }
 
@@ -79,7 +79,7 @@ func syncMemFSFilesystemDirectory(fs billy.Filesystem, remoteDir string, localDi
 		return fmt.Errorf("failed to read remote directory %s: %w", remoteDir, err)
 	}
 
-	logger.Debug("Found %d files in remote directory %s", len(remoteFiles), remoteDir)
+	gitLogger.Debug("Found %d files in remote directory %s", len(remoteFiles), remoteDir)
 
 	// Ensure local directory exists
 	if err := os.MkdirAll(localDir, 0750); err != nil {
@@ -99,7 +99,7 @@ func syncMemFSFilesystemDirectory(fs billy.Filesystem, remoteDir string, localDi
 		}
 	}
 
-	logger.Info("Successfully synced directory %s to %s", remoteDir, localDir)
+	gitLogger.Info("Successfully synced directory %s to %s", remoteDir, localDir)
 	return nil
 }
 
@@ -112,7 +112,7 @@ func syncFile(fs billy.Filesystem, remotePath, localPath string) error {
 	defer func() {
 		err := remoteFile.Close()
 		if err != nil {
-			logger.Error("Failed to close remote file: %v", err)
+			gitLogger.Error("Failed to close remote file: %v", err)
 		}
 	}()
 
@@ -138,16 +138,16 @@ func syncFile(fs billy.Filesystem, remotePath, localPath string) error {
 	// Determine if we need to update
 	shouldUpdate := false
 	if !localExists {
-		logger.Debug("Local file %s does not exist, creating new file", localPath)
+		gitLogger.Debug("Local file %s does not exist, creating new file", localPath)
 		shouldUpdate = true
 	} else {
 		localHash := calculateHash(localContent)
 		if remoteHash != localHash {
-			logger.Warn("File hash mismatch for %s - overwriting local file with remote version", localPath)
-			logger.Debug("Local hash: %s, Remote hash: %s", localHash, remoteHash)
+			gitLogger.Warn("File hash mismatch for %s - overwriting local file with remote version", localPath)
+			gitLogger.Debug("Local hash: %s, Remote hash: %s", localHash, remoteHash)
 			shouldUpdate = true
 		} else {
-			logger.Debug("File %s is up to date, skipping", localPath)
+			gitLogger.Debug("File %s is up to date, skipping", localPath)
 		}
 	}
 
@@ -163,9 +163,9 @@ func syncFile(fs billy.Filesystem, remotePath, localPath string) error {
 		}
 
 		if localExists {
-			logger.Info("Overwrote local file: %s", localPath)
+			gitLogger.Info("Overwrote local file: %s", localPath)
 		} else {
-			logger.Info("Created new file: %s", localPath)
+			gitLogger.Info("Created new file: %s", localPath)
 		}
 	}
 
@@ -0,0 +1,259 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+)
+
+// FileHistory holds the first and last commit that touched a content file
+type FileHistory struct {
+	Created    time.Time
+	CreatedBy  string
+	Modified   time.Time
+	ModifiedBy string
+}
+
+// GetContentHistory walks the history of the repository holding the content
+// and returns the first and last commit of every markdown file, keyed by its
+// path under serverConfig.ContentPath (as returned by htmlcompiler.GetMDFiles)
+func GetContentHistory(serverConfig *config.ServerConfig) (map[string]FileHistory, error) {
+	repo, prefix, err := openContentRepository(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := getFileHistory(repo, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[string]FileHistory, len(paths))
+	for repoPath, fileHistory := range paths {
+		relPath := strings.TrimPrefix(repoPath, prefix)
+		history[filepath.Join(serverConfig.ContentPath, filepath.FromSlash(relPath))] = fileHistory
+	}
+
+	gitLogger.Debug("Found git history for %d content files", len(history))
+	return history, nil
+}
+
+// openContentRepository opens the repository the content comes from and
+// returns the slash separated prefix of the content directory inside it
+func openContentRepository(serverConfig *config.ServerConfig) (*git.Repository, string, error) {
+	if serverConfig.GitRemoteContentURL != "" && serverConfig.HasGitRemoteContentDirectory() {
+		repo, err := openGitRepository(constants.GitRemoteContentDirectory)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open git remote content repository: %w", err)
+		}
+		return repo, contentPrefix(serverConfig.GitRemoteContentDirectory), nil
+	}
+
+	// local content, find the repository the content path belongs to
+	repo, err := git.PlainOpenWithOptions(serverConfig.ContentPath, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("content path %s is not in a git repository: %w", serverConfig.ContentPath, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	contentPath, err := filepath.Abs(serverConfig.ContentPath)
+	if err != nil {
+		return nil, "", err
+	}
+	rootPath, err := filepath.Abs(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, "", err
+	}
+	relPath, err := filepath.Rel(rootPath, contentPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return repo, contentPrefix(relPath), nil
+}
+
+func contentPrefix(directory string) string {
+	prefix := path.Clean(filepath.ToSlash(directory))
+	if prefix == "." || prefix == "/" {
+		return ""
+	}
+	return strings.Trim(prefix, "/") + "/"
+}
+
+// getFileHistory walks the commits reachable from HEAD, newest first, and
+// records the first and last commit of the markdown files under prefix that
+// exist in HEAD. Renames are followed so a moved page keeps its creation date
+func getFileHistory(repo *git.Repository, prefix string) (map[string]FileHistory, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+
+	history := map[string]FileHistory{}
+	err = headTree.Files().ForEach(func(file *object.File) error {
+		if isContentFile(file.Name, prefix) {
+			history[file.Name] = FileHistory{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in HEAD: %w", err)
+	}
+
+	// maps a path in an older commit to the path of the same file in HEAD,
+	// an empty value means the file at that path is no longer tracked
+	currentPaths := map[string]string{}
+	resolve := func(name string) string {
+		if current, ok := currentPaths[name]; ok {
+			return current
+		}
+		return name
+	}
+
+	commits, err := repo.Log(&git.LogOptions{
+		From:  head.Hash(),
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git log: %w", err)
+	}
+	defer commits.Close()
+
+	err = commits.ForEach(func(commit *object.Commit) error {
+		// merges only repeat changes already recorded on their parents
+		if commit.NumParents() > 1 {
+			return nil
+		}
+
+		changes, err := getCommitChanges(commit)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			// deletions do not touch a file that exists in HEAD
+			if change.To.Name == "" {
+				continue
+			}
+
+			current := resolve(change.To.Name)
+			fileHistory, ok := history[current]
+			if !ok {
+				continue
+			}
+
+			if fileHistory.Modified.IsZero() {
+				fileHistory.Modified = commit.Author.When
+				fileHistory.ModifiedBy = commit.Author.Name
+			}
+			fileHistory.Created = commit.Author.When
+			fileHistory.CreatedBy = commit.Author.Name
+			history[current] = fileHistory
+
+			switch {
+			case change.From.Name == "":
+				// the file was added here, anything older at this path is another file
+				currentPaths[change.To.Name] = ""
+			case change.From.Name != change.To.Name:
+				currentPaths[change.From.Name] = current
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk git log: %w", err)
+	}
+
+	return history, nil
+}
+
+// getCommitChanges diffs a commit against its first parent, or against an
+// empty tree for the root commit
+func getCommitChanges(commit *object.Commit) (object.Changes, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", commit.Hash, err)
+	}
+
+	var parentTree *object.Tree
+	if commit.NumParents() == 1 {
+		parent, err := commit.Parent(0)
+		switch {
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			// the parent is missing from a shallow clone, treat the commit as the root
+			gitLogger.Debug("Parent of commit %s not found, history is shallow", commit.Hash)
+		case err != nil:
+			return nil, fmt.Errorf("failed to get parent of commit %s: %w", commit.Hash, err)
+		default:
+			parentTree, err = parent.Tree()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tree of commit %s: %w", parent.Hash, err)
+			}
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(
+		context.Background(),
+		parentTree,
+		tree,
+		object.DefaultDiffTreeOptions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff commit %s: %w", commit.Hash, err)
+	}
+
+	return changes, nil
+}
+
+func isContentFile(name string, prefix string) bool {
+	return strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".md")
+}
+
+// GetContentCommit returns the hash of the HEAD commit of the repository the
+// content comes from: the git remote content clone, or the repository the
+// local content path belongs to
+func GetContentCommit(serverConfig *config.ServerConfig) (string, error) {
+	var repo *git.Repository
+	var err error
+	if serverConfig.GitRemoteContentURL != "" {
+		repo, err = openGitRepository(constants.GitRemoteContentDirectory)
+	} else {
+		repo, _, err = openContentRepository(serverConfig)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	return head.Hash().String(), nil
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestGetFileHistory(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	commit := func(day int, author string, message string) {
+		t.Helper()
+		if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			t.Fatalf("failed to stage changes: %v", err)
+		}
+		signature := &object.Signature{Name: author, Email: author + "@example.com", When: base.AddDate(0, 0, day)}
+		if _, err := worktree.Commit(message, &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	write := func(name string, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("content/first.md", "# First\n\nThe first page of the site with some content.\n")
+	write("content/old-name.md", "# Moved\n\nThis page is moved to another path later on in its life.\n")
+	write("README.md", "# Readme\n")
+	commit(0, "alice", "initial")
+
+	write("content/first.md", "# First\n\nThe first page of the site with some edited content.\n")
+	write("content/second.md", "# Second\n")
+	commit(5, "bob", "edit first, add second")
+
+	if err := os.Rename(filepath.Join(dir, "content/old-name.md"), filepath.Join(dir, "content/new-name.md")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Remove("content/old-name.md"); err != nil {
+		t.Fatal(err)
+	}
+	commit(9, "carol", "rename page")
+
+	history, err := getFileHistory(repo, "content/")
+	if err != nil {
+		t.Fatalf("getFileHistory returned error: %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		created    int
+		createdBy  string
+		modified   int
+		modifiedBy string
+	}{
+		{"content/first.md", 0, "alice", 5, "bob"},
+		{"content/second.md", 5, "bob", 5, "bob"},
+		{"content/new-name.md", 0, "alice", 9, "carol"},
+	}
+
+	if len(history) != len(tests) {
+		t.Fatalf("expected %d files in history, got %d: %v", len(tests), len(history), history)
+	}
+
+	for _, tt := range tests {
+		got, ok := history[tt.path]
+		if !ok {
+			t.Errorf("missing history for %s", tt.path)
+			continue
+		}
+		if !got.Created.Equal(base.AddDate(0, 0, tt.created)) || got.CreatedBy != tt.createdBy {
+			t.Errorf("%s created = %v by %s, want day %d by %s", tt.path, got.Created, got.CreatedBy, tt.created, tt.createdBy)
+		}
+		if !got.Modified.Equal(base.AddDate(0, 0, tt.modified)) || got.ModifiedBy != tt.modifiedBy {
+			t.Errorf("%s modified = %v by %s, want day %d by %s", tt.path, got.Modified, got.ModifiedBy, tt.modified, tt.modifiedBy)
+		}
+	}
+}
+
+func TestContentPrefix(t *testing.T) {
+	tests := map[string]string{
+		"":              "",
+		".":             "",
+		"content":       "content/",
+		"content/":      "content/",
+		"./docs/md":     "docs/md/",
+		"/content/sub/": "content/sub/",
+	}
+	for input, want := range tests {
+		if got := contentPrefix(input); got != want {
+			t.Errorf("contentPrefix(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/observe"
+)
+
+// RequestIDHeader carries the ID of a request, propagated from the client or
+// proxy when it sends a valid one
+const RequestIDHeader = "X-Request-ID"
+
+// request IDs of clients longer than this are replaced
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request of ctx, set by AccessLog
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns the logger of the app adding the request ID to its lines
+func requestLogger(app *App, r *http.Request) *logger.Logger {
+	id := RequestID(r.Context())
+	if id == "" {
+		return app.Logger
+	}
+	return app.Logger.With("request_id", id)
+}
+
+// AccessLog logs every request served by next with its method, path, status,
+// size, duration, client IP and user agent. The X-Forwarded-For header is only
+// honored for requests from trustedProxies. Each request gets an ID, sent back
+// in the X-Request-ID header and available to the handlers with RequestID
+func AccessLog(accessLogger *logger.Logger, trustedProxies []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		rw := observe.NewResponseRecorder(w)
+		next.ServeHTTP(rw, r)
+
+		accessLogger.With(
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.Status(),
+			"bytes", rw.Bytes(),
+			"duration", time.Since(start),
+			"remote_ip", clientIP(r, trustedProxies),
+			"user_agent", r.UserAgent(),
+			"request_id", id,
+		).Info("%s %s %d", r.Method, r.URL.Path, rw.Status())
+	})
+}
+
+// clientIP returns the IP of the client of r. Requests from a trusted proxy
+// are attributed to the last address of X-Forwarded-For that is not a
+// trusted proxy itself
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !isTrustedProxy(remote, trustedProxies) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := host
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// a malformed entry was not added by a trusted proxy
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrustedProxy(addr, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidRequestID reports whether a request ID sent by a client can be
+// propagated: printable ASCII without spaces, so it cannot forge log lines
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+func TestClientIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		wantClientIP string
+	}{
+		{"direct client", "203.0.113.7:1234", nil, "203.0.113.7"},
+		{"untrusted client spoofing", "203.0.113.7:1234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:1234", []string{"198.51.100.1, 192.0.2.1"}, "198.51.100.1"},
+		{"spoofed entry before the client", "10.1.2.3:1234", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"multiple headers", "10.1.2.3:1234", []string{"1.1.1.1", "198.51.100.1"}, "198.51.100.1"},
+		{"only trusted proxies", "10.1.2.3:1234", []string{"10.9.9.9"}, "10.9.9.9"},
+		{"malformed entry", "10.1.2.3:1234", []string{"198.51.100.1, garbage"}, "10.1.2.3"},
+		{"trusted proxy without header", "10.1.2.3:1234", nil, "10.1.2.3"},
+		{"IPv6 client", "[2001:db8::1]:1234", nil, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := clientIP(req, trustedProxies); got != tt.wantClientIP {
+				t.Errorf("clientIP() = %q, want %q", got, tt.wantClientIP)
+			}
+		})
+	}
+}
+
+func TestAccessLogRequestID(t *testing.T) {
+	var seen string
+	handler := AccessLog(logger.New("Test", logger.ErrorLevel), nil, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			seen = RequestID(r.Context())
+		},
+	))
+
+	tests := []struct {
+		name       string
+		requestID  string
+		propagated bool
+	}{
+		{"generated", "", false},
+		{"propagated", "abc-123", true},
+		{"with spaces", "abc 123", false},
+		{"with a newline", "abc\n123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response ID %q, handler ID %q, want the same non-empty ID", got, seen)
+			}
+			if tt.propagated != (got == tt.requestID) {
+				t.Errorf("request ID %q answered with %q, propagated = %v", tt.requestID, got, tt.propagated)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jaysongiroux/mdserve/internal/build"
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"go.yaml.in/yaml/v3"
+)
+
+// build statuses of the admin API
+const (
+	AdminBuildLive   = "live"
+	AdminBuildKept   = "kept"
+	AdminBuildFailed = "failed"
+)
+
+// AdminOperations are the operations of the admin API that change the live
+// build. They are implemented by main through the build coordinator
+type AdminOperations struct {
+	// Regenerate triggers a full generation in the background
+	Regenerate func(callerName string)
+	// Rollback makes a kept build live again
+	Rollback func(id string) error
+	// ClearCaches deletes the kept builds other than the live one, so the
+	// rollback history, and the clone of the git remote content
+	ClearCaches func() error
+	// FailedBuilds returns the builds that failed since startup, newest first
+	FailedBuilds func() []build.Build
+}
+
+type AdminBuild struct {
+	build.Build
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type AdminBuilds struct {
+	// id of the live build
+	Current string       `json:"current"`
+	Builds  []AdminBuild `json:"builds"`
+}
+
+// AdminData is the data of the admin dashboard template
+type AdminData struct {
+	Builds AdminBuilds
+	// effective config with secrets redacted, as YAML
+	Config string
+}
+
+type adminResponse struct {
+	Status string `json:"status"`
+}
+
+// RequireAdminToken serves the admin API to requests authenticated with the
+// admin token, sent as a bearer token or as the password of basic auth so
+// browsers can open the dashboard. Requests changing the live build from a
+// browser must come from the same origin
+func RequireAdminToken(app *App, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		if !hasAdminToken(r, app.ServerConfig.AdminToken) {
+			app.Logger.Warn("Rejected admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Basic realm="MDServe admin", charset="UTF-8"`)
+			writeAPIError(w, http.StatusUnauthorized, "invalid or missing admin token")
+			return
+		}
+
+		if r.Method != http.MethodGet && !isSameOrigin(r) {
+			writeAPIError(w, http.StatusForbidden, "cross-origin admin requests are not allowed")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// HandleAdminDashboard serves GET /admin/, the dashboard of the builds and the config
+func HandleAdminDashboard(app *App, operations AdminOperations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		builds, err := listAdminBuilds(app, operations)
+		if err != nil {
+			app.Logger.Error("Failed to list builds: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to list builds")
+			return
+		}
+
+		effectiveConfig, err := config.GetEffectiveConfig(app.ServerConfig, app.SiteConfig)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "failed to read config")
+			return
+		}
+		configYAML, err := yaml.Marshal(effectiveConfig)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "failed to encode config")
+			return
+		}
+
+		if app.Templates.Lookup("admin.html") == nil {
+			writeAPIError(w, http.StatusInternalServerError, "the admin.html template is missing from the templates directory")
+			return
+		}
+
+		pageName := "Admin - " + app.SiteConfig.Site.Name
+		data := newTemplateData(app)
+		data.PageName = &pageName
+		data.Admin = &AdminData{Builds: *builds, Config: string(configYAML)}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := executeTemplate(r.Context(), app, w, "admin.html", data); err != nil {
+			app.Logger.Error("Failed to execute admin template: %v", err)
+		}
+	}
+}
+
+// HandleAdminBuilds serves GET /admin/builds, the live, kept and failed builds
+func HandleAdminBuilds(app *App, operations AdminOperations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		builds, err := listAdminBuilds(app, operations)
+		if err != nil {
+			app.Logger.Error("Failed to list builds: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to list builds")
+			return
+		}
+		writeAPIJSON(w, r, builds)
+	}
+}
+
+// HandleAdminRebuild serves POST /admin/builds, which triggers a full
+// generation in the background
+func HandleAdminRebuild(app *App, operations AdminOperations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.Logger.Info("Rebuild requested from the admin API by %s", r.RemoteAddr)
+		operations.Regenerate("Admin")
+		writeAdminResponse(w, http.StatusAccepted, "accepted")
+	}
+}
+
+// HandleAdminRollback serves POST /admin/builds/{id}/rollback
+func HandleAdminRollback(app *App, operations AdminOperations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		err := operations.Rollback(id)
+		if errors.Is(err, build.ErrBuildNotFound) {
+			writeAPIError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			app.Logger.Error("Failed to roll back to build %s: %v", id, err)
+			writeAPIError(w, http.StatusConflict, err.Error())
+			return
+		}
+		app.Logger.Info("Rolled back to build %s from the admin API", id)
+		writeAdminResponse(w, http.StatusOK, "rolled back")
+	}
+}
+
+// HandleAdminClearCaches serves POST /admin/cache/clear. Clearing the caches
+// removes the rollback history, only the live and previous builds are kept
+func HandleAdminClearCaches(app *App, operations AdminOperations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := operations.ClearCaches(); err != nil {
+			app.Logger.Error("Failed to clear caches: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		app.Logger.Info("Caches cleared from the admin API")
+		writeAdminResponse(w, http.StatusOK, "cleared")
+	}
+}
+
+// HandleAdminConfig serves GET /admin/config, the effective server and site
+// configs with secrets redacted
+func HandleAdminConfig(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		effectiveConfig, err := config.GetEffectiveConfig(app.ServerConfig, app.SiteConfig)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "failed to read config")
+			return
+		}
+		writeAPIJSON(w, r, effectiveConfig)
+	}
+}
+
+// listAdminBuilds returns the kept builds, the live one marked, followed by
+// the failed builds
+func listAdminBuilds(app *App, operations AdminOperations) (*AdminBuilds, error) {
+	generatedPath := app.ServerConfig.GeneratedPath
+	kept, err := build.List(generatedPath)
+	if err != nil {
+		return nil, err
+	}
+	current, err := build.Current(generatedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := &AdminBuilds{Current: current, Builds: []AdminBuild{}}
+	for _, b := range kept {
+		status := AdminBuildKept
+		if b.ID == current {
+			status = AdminBuildLive
+		}
+		builds.Builds = append(builds.Builds, newAdminBuild(b, status))
+	}
+	for _, b := range operations.FailedBuilds() {
+		builds.Builds = append(builds.Builds, newAdminBuild(b, AdminBuildFailed))
+	}
+	return builds, nil
+}
+
+func newAdminBuild(b build.Build, status string) AdminBuild {
+	return AdminBuild{Build: b, Status: status, DurationMS: b.Duration().Milliseconds()}
+}
+
+// hasAdminToken checks the bearer token or basic auth password of r against token
+func hasAdminToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	candidate, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		_, candidate, found = r.BasicAuth()
+	}
+	return found && subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1
+}
+
+// isSameOrigin reports whether a browser request comes from the site itself.
+// Requests without an Origin header do not come from a browser form or script
+func isSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == r.Host
+}
+
+func writeAdminResponse(w http.ResponseWriter, status int, result string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(adminResponse{Status: result})
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+const (
+	defaultAPIPageSize = 10
+	maxAPIPageSize     = 100
+)
+
+type APIPageList struct {
+	Pages      []htmlcompiler.SiteMapEntry `json:"pages"`
+	Page       int                         `json:"page"`
+	PageSize   int                         `json:"page_size"`
+	Total      int                         `json:"total"`
+	TotalPages int                         `json:"total_pages"`
+}
+
+type APIPage struct {
+	Entry    htmlcompiler.SiteMapEntry `json:"entry"`
+	Metadata *htmlcompiler.Metadata    `json:"metadata"`
+	TOC      []APIHeader               `json:"toc"`
+	HTML     string                    `json:"html"`
+}
+
+type APIHeader struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+type APITag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// HandleAPIPages serves GET /api/pages, the paginated site map. Pages can be
+// filtered with the path (regex), tag (repeatable) and from/to (creation date,
+// YYYY-MM-DD or RFC 3339) query parameters
+func HandleAPIPages(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		page, err := parsePositiveInt(query.Get("page"), 1)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid page: "+err.Error())
+			return
+		}
+
+		defaultPageSize := app.SiteConfig.Site.PageSize
+		if defaultPageSize <= 0 {
+			defaultPageSize = defaultAPIPageSize
+		}
+		pageSize, err := parsePositiveInt(query.Get("page_size"), defaultPageSize)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid page_size: "+err.Error())
+			return
+		}
+		pageSize = min(pageSize, maxAPIPageSize)
+
+		filter, err := parseAPIPageFilter(query)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		siteMap, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Error loading site map: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to load site map")
+			return
+		}
+
+		pages := filter.apply(*siteMap)
+		start := min((page-1)*pageSize, len(pages))
+		end := min(start+pageSize, len(pages))
+
+		writeAPIJSON(w, r, APIPageList{
+			Pages:      pages[start:end],
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      len(pages),
+			TotalPages: (len(pages) + pageSize - 1) / pageSize,
+		})
+	}
+}
+
+// HandleAPIPage serves GET /api/pages/{path...}, a page with its metadata,
+// table of contents and rendered HTML
+func HandleAPIPage(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagePath := strings.Trim(r.PathValue("path"), "/")
+		if pagePath == "" {
+			pagePath = "index"
+		}
+
+		entry, err := app.GetPage(r.Context(), pagePath)
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, "page not found")
+			return
+		}
+
+		content, err := loadPageContent(r.Context(), app, entry)
+		if err != nil {
+			pageErr := &PageError{}
+			if errors.As(err, &pageErr) && pageErr.Code == Err404Code {
+				writeAPIError(w, http.StatusNotFound, "page not found")
+				return
+			}
+			writeAPIError(w, http.StatusInternalServerError, "failed to load page content")
+			return
+		}
+
+		headers, err := htmlcompiler.GetHeaders(string(content))
+		if err != nil {
+			app.Logger.Error("Error getting headers: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to get table of contents")
+			return
+		}
+		toc := make([]APIHeader, 0, len(headers))
+		for _, header := range headers {
+			toc = append(toc, APIHeader{Level: header.Level, Text: header.Text})
+		}
+
+		writeAPIJSON(w, r, APIPage{
+			Entry:    *entry,
+			Metadata: entry.Metadata,
+			TOC:      toc,
+			HTML:     string(content),
+		})
+	}
+}
+
+// HandleAPITags serves GET /api/tags, every tag with its page count, most used first
+func HandleAPITags(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		siteMap, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Error loading site map: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to load site map")
+			return
+		}
+
+		writeAPIJSON(w, r, countTags(*siteMap))
+	}
+}
+
+func countTags(siteMap []htmlcompiler.SiteMapEntry) []APITag {
+	counts := map[string]int{}
+	for _, page := range siteMap {
+		if page.Metadata == nil {
+			continue
+		}
+		for _, tag := range page.Metadata.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]APITag, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, APITag{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	return tags
+}
+
+type apiPageFilter struct {
+	path *regexp.Regexp
+	tags []string
+	// creation date range, from is inclusive and to is exclusive
+	from time.Time
+	to   time.Time
+}
+
+func parseAPIPageFilter(query url.Values) (apiPageFilter, error) {
+	var filter apiPageFilter
+
+	if expression := query.Get("path"); expression != "" {
+		path, err := regexp.Compile(expression)
+		if err != nil {
+			return filter, fmt.Errorf("invalid path regex: %w", err)
+		}
+		filter.path = path
+	}
+
+	for _, tag := range query["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.tags = append(filter.tags, tag)
+		}
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, _, err := parseAPIDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date: %w", err)
+		}
+		filter.from = from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, dateOnly, err := parseAPIDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date: %w", err)
+		}
+		// a date without a time includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Nanosecond)
+		}
+		filter.to = to
+	}
+
+	return filter, nil
+}
+
+func (f apiPageFilter) apply(siteMap []htmlcompiler.SiteMapEntry) []htmlcompiler.SiteMapEntry {
+	pages := make([]htmlcompiler.SiteMapEntry, 0, len(siteMap))
+	for _, page := range siteMap {
+		if f.path != nil && !f.path.MatchString(page.Path) {
+			continue
+		}
+
+		if len(f.tags) > 0 {
+			if page.Metadata == nil {
+				continue
+			}
+			hasTags := true
+			for _, tag := range f.tags {
+				if !slices.ContainsFunc(page.Metadata.Tags, func(pageTag string) bool {
+					return strings.EqualFold(pageTag, tag)
+				}) {
+					hasTags = false
+					break
+				}
+			}
+			if !hasTags {
+				continue
+			}
+		}
+
+		created := htmlcompiler.GetCreationDate(page)
+		if !f.from.IsZero() && created.Before(f.from) {
+			continue
+		}
+		if !f.to.IsZero() && !created.Before(f.to) {
+			continue
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages
+}
+
+// parseAPIDate parses a YYYY-MM-DD or RFC 3339 date and reports whether it was date only
+func parseAPIDate(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, true, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, err
+}
+
+func parsePositiveInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if parsed < 1 {
+		return 0, fmt.Errorf("must be at least 1")
+	}
+	return parsed, nil
+}
+
+// writeAPIJSON writes a JSON response with a strong ETag of its body, and an
+// empty 304 response when the client already has it
+func writeAPIJSON(w http.ResponseWriter, r *http.Request, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+
+	if setValidators(w, r, strongETag(body), time.Time{}) {
+		writeNotModified(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(body)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Error: message})
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+func TestAPIPageFilter(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	siteMap := []htmlcompiler.SiteMapEntry{
+		{Path: "blog/posts/go", CreationDate: date("2025-03-10"), Metadata: &htmlcompiler.Metadata{Tags: []string{"Go", "Tutorial"}}},
+		{Path: "blog/posts/rust", CreationDate: date("2025-06-01"), Metadata: &htmlcompiler.Metadata{Tags: []string{"Rust"}}},
+		{Path: "blog/posts/old", Metadata: &htmlcompiler.Metadata{CreationDate: date("2024-12-31"), Tags: []string{"go"}}},
+		{Path: "about", CreationDate: date("2025-03-10")},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"No filter", "", "blog/posts/go,blog/posts/rust,blog/posts/old,about"},
+		{"Path regex", "path=^blog/", "blog/posts/go,blog/posts/rust,blog/posts/old"},
+		{"Tag is case insensitive", "tag=GO", "blog/posts/go,blog/posts/old"},
+		{"Every tag must match", "tag=go&tag=tutorial", "blog/posts/go"},
+		{"Date range includes the whole last day", "from=2025-01-01&to=2025-03-10", "blog/posts/go,about"},
+		{"Metadata creation date is used", "to=2024-12-31", "blog/posts/old"},
+		{"RFC 3339 dates", "from=2025-05-31T23:00:00Z", "blog/posts/rust"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := parseAPIPageFilter(query)
+			if err != nil {
+				t.Fatalf("parseAPIPageFilter returned error: %v", err)
+			}
+
+			var paths []string
+			for _, page := range filter.apply(siteMap) {
+				paths = append(paths, page.Path)
+			}
+			if got := strings.Join(paths, ","); got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	for _, query := range []string{"path=(", "from=yesterday", "to=2025-13-01"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := parseAPIPageFilter(values); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// LoadApp loads the app snapshot serving a finished build: the configs it was
+// generated with, its templates, site map, redirects and navigation tree
+func LoadApp(
+	serverConfig *config.ServerConfig,
+	siteConfig *config.SiteConfig,
+	appLogger *logger.Logger,
+	buildPath string,
+) (*App, error) {
+	app := &App{
+		ServerConfig:            serverConfig,
+		SiteConfig:              siteConfig,
+		Logger:                  appLogger,
+		Handler:                 HandlePage,
+		BuildPath:               buildPath,
+		TemplatesGeneratedPath:  filepath.Join(buildPath, constants.TemplatesPath),
+		AssetsGeneratedPath:     filepath.Join(buildPath, constants.GeneratedAssetsPath),
+		UserStaticGeneratedPath: filepath.Join(buildPath, constants.UserStaticPath),
+	}
+
+	appLogger.Info("Loading HTML templates...")
+	templates, err := ParseTemplates(app.TemplatesGeneratedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+	app.Templates = templates
+
+	siteMap, err := htmlcompiler.LoadSiteMap(filepath.Join(buildPath, constants.SiteMapPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load site map: %w", err)
+	}
+	app.SiteMap = *siteMap
+	app.pages = htmlcompiler.IndexSiteMap(app.SiteMap)
+	app.Redirects = htmlcompiler.NewRedirects(app.SiteMap, siteConfig.Redirects)
+
+	if siteConfig.Site.Navigation.Enabled {
+		navigation, err := htmlcompiler.LoadNavigation(filepath.Join(buildPath, constants.NavigationPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load navigation: %w", err)
+		}
+		app.Navigation = navigation
+	}
+
+	return app, nil
+}
+
+// LoadSiteMap returns a copy of the site map of the app, which callers may sort and filter
+func (app *App) LoadSiteMap() (*[]htmlcompiler.SiteMapEntry, error) {
+	if app.SiteMap == nil {
+		return nil, fmt.Errorf("site map of %s is not loaded", app.BuildPath)
+	}
+	siteMap := slices.Clone(app.SiteMap)
+	return &siteMap, nil
+}
+
+// GetPage returns the page of the site map at a site map path
+func (app *App) GetPage(ctx context.Context, path string) (*htmlcompiler.SiteMapEntry, error) {
+	_, span := tracing.Start(ctx, "sitemap.lookup", attribute.String("mdserve.page", path))
+	defer span.End()
+
+	page, ok := app.pages[path]
+	span.SetAttributes(attribute.Bool("mdserve.found", ok))
+	if !ok {
+		return nil, htmlcompiler.ErrPageNotFound
+	}
+	return &page, nil
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+func TestAppGetPage(t *testing.T) {
+	siteMap := []htmlcompiler.SiteMapEntry{
+		{Path: "index", FirstHeader: "Welcome"},
+		{Path: "blog/post", FirstHeader: "Post"},
+	}
+	app := &App{SiteMap: siteMap, pages: htmlcompiler.IndexSiteMap(siteMap)}
+
+	page, err := app.GetPage(context.Background(), "blog/post")
+	if err != nil || page.FirstHeader != "Post" {
+		t.Fatalf("GetPage() = %+v, %v, want the blog post", page, err)
+	}
+	// the page is a copy, the snapshot stays immutable
+	page.FirstHeader = "Changed"
+	if app.SiteMap[1].FirstHeader != "Post" || app.pages["blog/post"].FirstHeader != "Post" {
+		t.Error("modifying the returned page modified the snapshot")
+	}
+
+	if _, err := app.GetPage(context.Background(), "missing"); !errors.Is(err, htmlcompiler.ErrPageNotFound) {
+		t.Errorf("GetPage() of a missing page returned %v, want ErrPageNotFound", err)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+const defaultArchiveLayout = "archive_layout"
+
+type ArchiveData struct {
+	// Year and Month of the current archive page, 0 when not scoped to a period
+	Year  int
+	Month time.Month
+	// every archived year with its months and page counts, newest first
+	Years []htmlcompiler.ArchiveYear
+	// pages created in the current period
+	Pages []htmlcompiler.SiteMapEntry
+	Total int
+}
+
+// HandleArchive serves the /archive/, /archive/<year>/ and /archive/<year>/<month>/ pages
+func HandleArchive(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := newTemplateData(app)
+		archiveConfig := app.SiteConfig.Site.Archive
+
+		if !archiveConfig.Enabled {
+			handleError(app, w, r, NewPageError(Err404Code, Err404Title, Err404Message), &data)
+			return
+		}
+
+		year, month, err := parseArchivePeriod(r)
+		if err != nil {
+			app.Logger.Warn("404 Not Found: invalid archive period %s: %v", r.URL.Path, err)
+			handleError(app, w, r, NewPageError(Err404Code, Err404Title, Err404Message), &data)
+			return
+		}
+
+		siteMap, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Error loading site map: %v", err)
+			handleError(app, w, r, NewPageError(Err500Code, Err500Title, Err500Message), &data)
+			return
+		}
+
+		if archiveConfig.Filter != "" {
+			siteMap, err = htmlcompiler.FilterSiteMap(siteMap, archiveConfig.Filter)
+			if err != nil {
+				app.Logger.Error("Error filtering site map: %v", err)
+				handleError(app, w, r, NewPageError(Err500Code, Err500Title, Err500Message), &data)
+				return
+			}
+		}
+
+		archive := &ArchiveData{
+			Year:  year,
+			Month: month,
+			Years: htmlcompiler.BuildArchive(*siteMap),
+		}
+		for _, archiveYear := range archive.Years {
+			archive.Total += archiveYear.Count
+		}
+
+		if year == 0 {
+			archive.Pages = *siteMap
+		} else {
+			archive.Pages = htmlcompiler.FilterSiteMapByDate(*siteMap, year, month)
+			if len(archive.Pages) == 0 {
+				app.Logger.Warn("404 Not Found: no archived pages for %s", r.URL.Path)
+				handleError(app, w, r, NewPageError(Err404Code, Err404Title, Err404Message), &data)
+				return
+			}
+		}
+
+		pageName := "Archive"
+		if month != 0 {
+			pageName = fmt.Sprintf("Archive: %s %d", month, year)
+		} else if year != 0 {
+			pageName = fmt.Sprintf("Archive: %d", year)
+		}
+		data.PageName = &pageName
+		data.Archive = archive
+		data.PageList = &archive.Pages
+
+		layout := archiveConfig.Layout
+		if layout == "" {
+			layout = defaultArchiveLayout
+		}
+
+		// the page is rendered before it is written, so a failing template serves only the error page
+		var page bytes.Buffer
+		if err := renderNestedLayout(r.Context(), app, &page, layout+".html", &data); err != nil {
+			handleError(app, w, r, err, &data)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page.Bytes())
+	}
+}
+
+func parseArchivePeriod(r *http.Request) (int, time.Month, error) {
+	var year int
+	var month time.Month
+
+	if value := r.PathValue("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, fmt.Errorf("invalid year %q", value)
+		}
+		year = parsed
+	}
+
+	if value := r.PathValue("month"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 12 {
+			return 0, 0, fmt.Errorf("invalid month %q", value)
+		}
+		month = time.Month(parsed)
+	}
+
+	return year, month, nil
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+func TestHandleArchive(t *testing.T) {
+	templates := template.Must(template.New("error.html").Parse(`error {{.ErrorCode}}`))
+	template.Must(templates.New("layout.html").Parse(`{{.Content}}`))
+	template.Must(templates.New("archive_layout.html").Parse(`{{range .PageList}}{{.Path}} {{end}}`))
+
+	app := &App{
+		ServerConfig: &config.ServerConfig{},
+		SiteConfig:   &config.SiteConfig{Site: config.Site{Archive: config.Archive{Enabled: true}}},
+		Logger:       logger.New("Test", logger.ErrorLevel),
+		Templates:    templates,
+		SiteMap: []htmlcompiler.SiteMapEntry{
+			{Path: "blog/2024-march", CreationDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+			{Path: "blog/2025-june", CreationDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+			{Path: "about"},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /archive/{$}", HandleArchive(app))
+	mux.HandleFunc("GET /archive/{year}/{$}", HandleArchive(app))
+	mux.HandleFunc("GET /archive/{year}/{month}/{$}", HandleArchive(app))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Every page", "/archive/", http.StatusOK, "blog/2024-march blog/2025-june about "},
+		{"Year", "/archive/2025/", http.StatusOK, "blog/2025-june "},
+		{"Month", "/archive/2024/03/", http.StatusOK, "blog/2024-march "},
+		{"Month without pages", "/archive/2024/04/", http.StatusNotFound, "error 404"},
+		{"Month above 12", "/archive/2024/13/", http.StatusNotFound, "error 404"},
+		{"Month 0", "/archive/2024/00/", http.StatusNotFound, "error 404"},
+		{"Year not a number", "/archive/abc/", http.StatusNotFound, "error 404"},
+		{"Month not a number", "/archive/2024/abc/", http.StatusNotFound, "error 404"},
+		{"Year 0", "/archive/0/", http.StatusNotFound, "error 404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(recorder.Body.String()); got != strings.TrimSpace(tt.wantBody) {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleArchiveLayoutError(t *testing.T) {
+	templates := template.Must(template.New("error.html").Parse(`error {{.ErrorCode}}`))
+	// the layout fails after it wrote part of the page
+	template.Must(templates.New("layout.html").Parse(`{{.Content}}{{index .PageList 99}}`))
+	template.Must(templates.New("archive_layout.html").Parse(`{{range .PageList}}{{.Path}} {{end}}`))
+
+	app := &App{
+		ServerConfig: &config.ServerConfig{},
+		SiteConfig:   &config.SiteConfig{Site: config.Site{Archive: config.Archive{Enabled: true}}},
+		Logger:       logger.New("Test", logger.ErrorLevel),
+		Templates:    templates,
+		SiteMap:      []htmlcompiler.SiteMapEntry{{Path: "about"}},
+	}
+
+	recorder := httptest.NewRecorder()
+	HandleArchive(app)(recorder, httptest.NewRequest(http.MethodGet, "/archive/", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status of a failing layout = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+	if got := strings.TrimSpace(recorder.Body.String()); got != "error 500" {
+		t.Errorf("body of a failing layout = %q, want only the error page", got)
+	}
+}
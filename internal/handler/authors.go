@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+const defaultAuthorLayout = "author_layout"
+
+// HandleAuthor serves /authors/<id>/ pages listing the posts of an author defined in the site config
+func HandleAuthor(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := newTemplateData(app)
+
+		author, ok := app.SiteConfig.GetAuthor(r.PathValue("id"))
+		if !ok {
+			app.Logger.Warn("404 Not Found: unknown author %s", r.PathValue("id"))
+			handleError(app, w, r, NewPageError(Err404Code, Err404Title, Err404Message), &data)
+			return
+		}
+
+		siteMap, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Error loading site map: %v", err)
+			handleError(app, w, r, NewPageError(Err500Code, Err500Title, Err500Message), &data)
+			return
+		}
+
+		pages := make([]htmlcompiler.SiteMapEntry, 0)
+		for _, page := range *siteMap {
+			authors := app.SiteConfig.ResolveAuthors(htmlcompiler.GetAuthorNames(page))
+			if slices.ContainsFunc(authors, func(a config.Author) bool { return a.ID == author.ID }) {
+				pages = append(pages, page)
+			}
+		}
+
+		data.PageName = &author.Name
+		data.AuthorProfile = &author
+		data.PageList = &pages
+
+		layout := app.SiteConfig.Site.AuthorLayout
+		if layout == "" {
+			layout = defaultAuthorLayout
+		}
+
+		// the page is rendered before it is written, so a failing template serves only the error page
+		var page bytes.Buffer
+		if err := renderNestedLayout(r.Context(), app, &page, layout+".html", &data); err != nil {
+			handleError(app, w, r, err, &data)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page.Bytes())
+	}
+}
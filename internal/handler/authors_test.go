@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+func TestHandleAuthor(t *testing.T) {
+	newApp := func(layout string) *App {
+		templates := template.Must(template.New("error.html").Parse(`error {{.ErrorCode}}`))
+		template.Must(templates.New("layout.html").Parse(layout))
+		template.Must(templates.New("author_layout.html").Parse(`{{.PageName}}: {{range .PageList}}{{.Path}} {{end}}`))
+		return &App{
+			ServerConfig: &config.ServerConfig{},
+			SiteConfig:   &config.SiteConfig{Authors: []config.Author{{ID: "jdoe", Name: "Jane Doe"}}},
+			Logger:       logger.New("Test", logger.ErrorLevel),
+			Templates:    templates,
+			SiteMap: []htmlcompiler.SiteMapEntry{
+				{Path: "blog/go", Metadata: &htmlcompiler.Metadata{Author: "Jane Doe"}},
+				{Path: "blog/rust", Metadata: &htmlcompiler.Metadata{Authors: []string{"bsmith"}}},
+				{Path: "about"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		layout     string
+		id         string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Author", `{{.Content}}`, "jdoe", http.StatusOK, "Jane Doe: blog/go"},
+		{"Id is case insensitive", `{{.Content}}`, "JDoe", http.StatusOK, "Jane Doe: blog/go"},
+		{"Unknown author", `{{.Content}}`, "bsmith", http.StatusNotFound, "error 404"},
+		// the layout fails after it wrote part of the page
+		{"Failing layout", `{{.Content}}{{index .PageList 99}}`, "jdoe", http.StatusInternalServerError, "error 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/authors/"+tt.id+"/", nil)
+			request.SetPathValue("id", tt.id)
+			recorder := httptest.NewRecorder()
+			HandleAuthor(newApp(tt.layout))(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(recorder.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
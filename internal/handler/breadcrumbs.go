@@ -0,0 +1,32 @@
+package handler
+
+import (
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+// breadcrumbListJSONLD builds the schema.org BreadcrumbList structured data
+// of a breadcrumb trail. Steps without a page are left out
+func breadcrumbListJSONLD(baseURL string, breadcrumbs []htmlcompiler.Breadcrumb) map[string]any {
+	items := make([]map[string]any, 0, len(breadcrumbs))
+	for _, breadcrumb := range breadcrumbs {
+		if breadcrumb.URL == "" {
+			continue
+		}
+		items = append(items, map[string]any{
+			"@type":    "ListItem",
+			"position": len(items) + 1,
+			"name":     breadcrumb.Title,
+			"item":     baseURL + htmlcompiler.EscapeURLPath(breadcrumb.URL),
+		})
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return map[string]any{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
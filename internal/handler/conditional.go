@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+// strongETag returns a strong ETag of a response body
+func strongETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// pageLastModified returns when the rendered page last changed: the later of
+// the modification date of the page and the end of the build, as the
+// templates, navigation and site config of the build are rendered with it
+func pageLastModified(app *App, page *htmlcompiler.SiteMapEntry) time.Time {
+	lastModified := htmlcompiler.GetModifiedDate(*page)
+	if app.Build != nil && app.Build.FinishedAt.After(lastModified) {
+		return app.Build.FinishedAt
+	}
+	return lastModified
+}
+
+// setValidators sets the ETag and, when known, the Last-Modified date of a
+// response. It reports whether the copy of the client is current: its
+// If-None-Match matches the ETag or, without If-None-Match, the response was
+// not modified since its If-Modified-Since
+func setValidators(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	known := !lastModified.IsZero() && !lastModified.Equal(time.Unix(0, 0))
+	if known {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	if !known || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified has a precision of a second
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// etagMatches reports whether an If-None-Match header matches an ETag, using
+// the weak comparison required for If-None-Match
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified writes an empty 304 response, without the headers of the
+// body the client already has
+func writeNotModified(w http.ResponseWriter) {
+	w.Header().Del("Content-Type")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+}
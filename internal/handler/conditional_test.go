@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/build"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+func TestSetValidators(t *testing.T) {
+	lastModified := time.Date(2025, 3, 10, 12, 30, 45, 500, time.UTC)
+	etag := strongETag([]byte("<html></html>"))
+
+	tests := []struct {
+		name            string
+		method          string
+		ifNoneMatch     string
+		ifModifiedSince string
+		lastModified    time.Time
+		wantCurrent     bool
+	}{
+		{"No validators", http.MethodGet, "", "", lastModified, false},
+		{"ETag matches", http.MethodGet, etag, "", lastModified, true},
+		{"ETag in a list", http.MethodGet, `"other", ` + etag, "", lastModified, true},
+		{"Any ETag", http.MethodGet, "*", "", lastModified, true},
+		{"ETag differs", http.MethodGet, `"other"`, "", lastModified, false},
+		{"Not modified since", http.MethodGet, "", "Mon, 10 Mar 2025 12:30:45 GMT", lastModified, true},
+		{"Modified since", http.MethodGet, "", "Mon, 10 Mar 2025 12:30:44 GMT", lastModified, false},
+		{"If-None-Match takes precedence", http.MethodGet, `"other"`, "Mon, 10 Mar 2025 12:30:45 GMT", lastModified, false},
+		{"Unknown modification date", http.MethodGet, "", "Mon, 10 Mar 2025 12:30:45 GMT", time.Time{}, false},
+		{"If-Modified-Since ignored for POST", http.MethodPost, "", "Mon, 10 Mar 2025 12:30:45 GMT", lastModified, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			rec := httptest.NewRecorder()
+
+			if got := setValidators(rec, req, etag, tt.lastModified); got != tt.wantCurrent {
+				t.Errorf("setValidators() = %v, want %v", got, tt.wantCurrent)
+			}
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			wantLastModified := ""
+			if !tt.lastModified.IsZero() {
+				wantLastModified = "Mon, 10 Mar 2025 12:30:45 GMT"
+			}
+			if got := rec.Header().Get("Last-Modified"); got != wantLastModified {
+				t.Errorf("Last-Modified = %q, want %q", got, wantLastModified)
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	tests := map[string]bool{
+		"":                false,
+		`"abc"`:           true,
+		`W/"abc"`:         true,
+		`"xyz", "abc"`:    true,
+		"*":               true,
+		`"xyz"`:           false,
+		`"abc-gzip"`:      false,
+		`W/"xyz",W/"123"`: false,
+	}
+	for header, expected := range tests {
+		if got := etagMatches(header, etag); got != expected {
+			t.Errorf("etagMatches(%q) = %v, want %v", header, got, expected)
+		}
+	}
+}
+
+func TestStrongETag(t *testing.T) {
+	etag := strongETag([]byte("page"))
+	if etag != strongETag([]byte("page")) {
+		t.Error("ETag of the same body differs")
+	}
+	if etag == strongETag([]byte("other page")) {
+		t.Error("ETag of different bodies is the same")
+	}
+	if len(etag) != 34 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		t.Errorf("ETag %s is not a quoted strong entity tag", etag)
+	}
+}
+
+func TestPageLastModified(t *testing.T) {
+	modified := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	page := &htmlcompiler.SiteMapEntry{Path: "about", LastModifiedDate: modified}
+
+	tests := []struct {
+		name  string
+		build *build.Build
+		want  time.Time
+	}{
+		{"No build", nil, modified},
+		{"Build before the page changed", &build.Build{FinishedAt: modified.Add(-time.Hour)}, modified},
+		{"Build after the page changed", &build.Build{FinishedAt: modified.Add(time.Hour)}, modified.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageLastModified(&App{Build: tt.build}, page); !got.Equal(tt.want) {
+				t.Errorf("pageLastModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,42 +1,37 @@
 package handler
 
 import (
+	"context"
 	"html/template"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/jaysongiroux/mdserve/internal/constants"
 	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/metrics"
+	"github.com/jaysongiroux/mdserve/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func loadPageContent(app *App, pageName, mdPath string) (template.HTML, error) {
+func loadPageContent(ctx context.Context, app *App, entry *htmlcompiler.SiteMapEntry) (template.HTML, error) {
 	if app.ServerConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
-		return loadStaticHTML(app, pageName)
+		return loadStaticHTML(app, entry.Path)
 	}
-	return loadAndCompileMarkdown(app, pageName, mdPath)
+	mdPath := filepath.Join(app.ServerConfig.ContentPath, filepath.FromSlash(entry.SourcePath))
+	return loadAndCompileMarkdown(ctx, app, mdPath)
 }
 
 func loadStaticHTML(app *App, pageName string) (template.HTML, error) {
 	htmlPath := filepath.Join(
-		app.ServerConfig.GeneratedPath,
+		app.BuildPath,
 		constants.HTMLFilesPath,
 		pageName+".html",
 	)
 	contentBytes, err := os.ReadFile(filepath.Clean(htmlPath))
-
 	if os.IsNotExist(err) {
-		// Try with /index.html appended
-		indexPath := filepath.Join(
-			app.ServerConfig.GeneratedPath,
-			constants.HTMLFilesPath,
-			pageName,
-			"index.html",
-		)
-		contentBytes, err = os.ReadFile(filepath.Clean(indexPath))
-		if os.IsNotExist(err) {
-			app.Logger.Warn("404 Not Found: %s", htmlPath)
-			return "", NewPageError(Err404Code, Err404Title, Err404Message)
-		}
+		app.Logger.Warn("404 Not Found: %s", htmlPath)
+		return "", NewPageError(Err404Code, Err404Title, Err404Message)
 	}
 
 	if err != nil {
@@ -48,19 +43,18 @@ func loadStaticHTML(app *App, pageName string) (template.HTML, error) {
 	return template.HTML(contentBytes), nil
 }
 
-func loadAndCompileMarkdown(app *App, pageName, mdPath string) (template.HTML, error) {
+func loadAndCompileMarkdown(ctx context.Context, app *App, mdPath string) (template.HTML, error) {
 	// Check if file exists
 	if _, err := os.Stat(mdPath); os.IsNotExist(err) {
-		// Try with /index.md appended
-		indexPath := filepath.Join(app.ServerConfig.ContentPath, pageName, "index.md")
-		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-			app.Logger.Warn("404 Not Found: %s", mdPath)
-			return "", NewPageError(Err404Code, Err404Title, Err404Message)
-		}
-		mdPath = indexPath
+		app.Logger.Warn("404 Not Found: %s", mdPath)
+		return "", NewPageError(Err404Code, Err404Title, Err404Message)
 	}
 
-	htmlString, err := htmlcompiler.CompileHTMLFile(mdPath, app.SiteConfig)
+	_, span := tracing.Start(ctx, "compile.live", attribute.String("mdserve.file", mdPath))
+	start := time.Now()
+	htmlString, err := htmlcompiler.CompileHTMLFile(mdPath, app.SiteConfig, app.ServerConfig.ContentPath)
+	metrics.ObserveLiveCompile(time.Since(start))
+	tracing.End(span, err)
 	if err != nil {
 		app.Logger.Error("Error compiling markdown live: %v", err)
 		return "", NewPageError(Err500Code, Err500Title, Err500Message)
@@ -70,26 +64,10 @@ func loadAndCompileMarkdown(app *App, pageName, mdPath string) (template.HTML, e
 	return template.HTML(htmlString), nil
 }
 
-func getHTMLContent(app *App, pageName, mdPath string) (string, error) {
-	if app.ServerConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
-		htmlContentBytes, err := os.ReadFile(filepath.Clean(
-			filepath.Join(
-				app.ServerConfig.GeneratedPath,
-				constants.HTMLFilesPath,
-				pageName+".html",
-			)),
-		)
-		if err != nil {
-			app.Logger.Error("Error reading html content: %v", err)
-			return "", NewPageError(Err500Code, Err500Title, Err500Message)
-		}
-		return string(htmlContentBytes), nil
-	}
-
-	compiledHTML, err := htmlcompiler.CompileHTMLFile(mdPath, app.SiteConfig)
+func getHTMLContent(ctx context.Context, app *App, entry *htmlcompiler.SiteMapEntry) (string, error) {
+	content, err := loadPageContent(ctx, app, entry)
 	if err != nil {
-		app.Logger.Error("Error compiling markdown: %v", err)
-		return "", NewPageError(Err500Code, Err500Title, Err500Message)
+		return "", err
 	}
-	return compiledHTML, nil
+	return string(content), nil
 }
@@ -4,8 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-
-	"github.com/jaysongiroux/mdserve/internal/logger"
+	"strconv"
 )
 
 type PageError struct {
@@ -26,7 +25,7 @@ func NewPageError(code, title, message string) *PageError {
 	}
 }
 
-func handleError(app *App, w http.ResponseWriter, err error, data *TemplateData) {
+func handleError(app *App, w http.ResponseWriter, r *http.Request, err error, data *TemplateData) {
 	pageErr := &PageError{}
 	if errors.As(err, &pageErr) {
 		data.ErrorCode = &pageErr.Code
@@ -37,8 +36,14 @@ func handleError(app *App, w http.ResponseWriter, err error, data *TemplateData)
 		data.ErrorTitle = &Err500Title
 		data.ErrorMessage = &Err500Message
 	}
-	err = app.Templates.ExecuteTemplate(w, "error.html", data)
+	// the error page is served with the status of its code, ex. 404
+	status, convErr := strconv.Atoi(*data.ErrorCode)
+	if convErr != nil {
+		status = http.StatusInternalServerError
+	}
+	w.WriteHeader(status)
+	err = executeTemplate(r.Context(), app, w, "error.html", data)
 	if err != nil {
-		logger.Error("Failed to execute template: %v", err)
+		requestLogger(app, r).Error("Failed to execute template: %v", err)
 	}
 }
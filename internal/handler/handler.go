@@ -1,19 +1,23 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
-	"path/filepath"
 	"strings"
 
-	"github.com/jaysongiroux/mdserve/internal/constants"
+	"github.com/jaysongiroux/mdserve/internal/config"
 	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
 )
 
 var (
 	Err404Code    = "404"
+	Err410Code    = "410"
 	Err500Code    = "500"
 	Err404Title   = "Page Not Found"
 	Err404Message = "The page you're looking for doesn't exist. It might have been moved, deleted, or you entered the wrong URL."
+	Err410Title   = "Page Removed"
+	Err410Message = "The page you're looking for has been permanently removed."
 	Err500Title   = "Internal Server Error"
 	Err500Message = "An unexpected error occurred while processing your request."
 )
@@ -25,26 +29,36 @@ const (
 
 func HandlePage(app *App, w http.ResponseWriter, r *http.Request) {
 	pageName := getPageName(r.URL.Path)
+	pageLogger := requestLogger(app, r)
 
 	// Initialize template data
 	data := newTemplateData(app)
 
-	// Load page content
-	mdPath := filepath.Join(app.ServerConfig.ContentPath, pageName+".md")
-	contentHTML, err := loadPageContent(app, pageName, mdPath)
+	// Load sitemap metadata
+	sitemapEntity, err := app.GetPage(r.Context(), pageName)
 	if err != nil {
-		handleError(app, w, err, &data)
+		if handleRedirect(app, w, r, &data) {
+			return
+		}
+		if errors.Is(err, htmlcompiler.ErrPageNotFound) {
+			pageLogger.Warn("404 Not Found: %s", r.URL.Path)
+			err = NewPageError(Err404Code, Err404Title, Err404Message)
+		}
+		handleError(app, w, r, err, &data)
 		return
 	}
-	data.Content = contentHTML
 
-	// Load sitemap metadata
-	sitemapPath := filepath.Join(app.ServerConfig.GeneratedPath, constants.SiteMapPath)
-	sitemapEntity, err := htmlcompiler.GetSitemapEntityByPath(pageName, sitemapPath)
+	if redirectToCanonicalURL(app, w, r, sitemapEntity) {
+		return
+	}
+
+	// Load page content
+	contentHTML, err := loadPageContent(r.Context(), app, sitemapEntity)
 	if err != nil {
-		handleError(app, w, err, &data)
+		handleError(app, w, r, err, &data)
 		return
 	}
+	data.Content = contentHTML
 
 	data.CreationDate = htmlcompiler.GetCreationDate(*sitemapEntity)
 	if sitemapEntity.Metadata != nil {
@@ -55,42 +69,83 @@ func HandlePage(app *App, w http.ResponseWriter, r *http.Request) {
 
 	// set the sitemap entity
 	data.SiteMapEntity = sitemapEntity
+	data.Authors = app.SiteConfig.ResolveAuthors(htmlcompiler.GetAuthorNames(*sitemapEntity))
+	data.Navigation = htmlcompiler.MarkNavigation(data.Navigation, sitemapEntity.Path)
+
+	applyPageRelations(app, sitemapEntity, &data)
+	data.BreadcrumbList = breadcrumbListJSONLD(getBaseURL(app, r), data.Breadcrumbs)
 
 	// Determine layout
 	layoutFile, layoutFilter := determineLayout(app, pageName)
 
 	// Apply layout filter if specified
 	if layoutFilter != "" {
-		if err := applyLayoutFilter(app, layoutFilter, sitemapPath, &data); err != nil {
-			handleError(app, w, err, &data)
+		if err := applyLayoutFilter(app, layoutFilter, &data); err != nil {
+			handleError(app, w, r, err, &data)
 			return
 		}
 	}
 
+	// the page is rendered before it is written, its ETag is a hash of its bytes
+	var page bytes.Buffer
+
 	// Handle custom layouts
 	if layoutFile != defaultLayoutFile {
-		if err := renderCustomLayout(app, w, layoutFile, pageName, mdPath, &data); err != nil {
-			handleError(app, w, err, &data)
+		if err := renderCustomLayout(r.Context(), app, &page, layoutFile, sitemapEntity, &data); err != nil {
+			handleError(app, w, r, err, &data)
 			return
 		}
 	} else {
-		app.Logger.Info("Using default layout: %s", defaultLayoutFile)
-		if err := app.Templates.ExecuteTemplate(w, defaultLayoutFile, data); err != nil {
-			app.Logger.Error("Template execution error: %v", err)
+		pageLogger.Info("Using default layout: %s", defaultLayoutFile)
+		if err := executeTemplate(r.Context(), app, &page, defaultLayoutFile, data); err != nil {
+			pageLogger.Error("Template execution error: %v", err)
+			handleError(app, w, r, NewPageError(Err500Code, Err500Title, Err500Message), &data)
+			return
 		}
 	}
 
-	w.Header().Set("Content-Type", "text/html")
+	if setValidators(w, r, strongETag(page.Bytes()), pageLastModified(app, sitemapEntity)) {
+		writeNotModified(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page.Bytes())
 }
 
+// getPageName returns the site map path of a request path. Page paths are
+// matched with or without a trailing slash, the root is the index page
 func getPageName(path string) string {
-	if path == "/" {
+	pageName := strings.Trim(path, "/")
+	if pageName == "" {
 		return "index"
 	}
+	return pageName
+}
+
+// redirectToCanonicalURL permanently redirects a page request to the canonical
+// URL of the page when the trailing slash policy is always or never and the
+// request path differs. It reports whether the request was redirected
+func redirectToCanonicalURL(
+	app *App,
+	w http.ResponseWriter,
+	r *http.Request,
+	entry *htmlcompiler.SiteMapEntry,
+) bool {
+	if app.SiteConfig.Site.URLs.TrailingSlash == config.TrailingSlashIgnore {
+		return false
+	}
 
-	pageName := strings.TrimPrefix(path, "/")
-	if strings.HasSuffix(pageName, "/") {
-		return pageName + "index"
+	canonical := htmlcompiler.GetURLPath(*entry)
+	if r.URL.Path == canonical {
+		return false
 	}
-	return pageName
+
+	target := htmlcompiler.EscapeURLPath(canonical)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	requestLogger(app, r).Info("301 Canonical redirect: %s -> %s", r.URL.Path, target)
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
 }
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/constants"
+	"github.com/jaysongiroux/mdserve/internal/version"
+)
+
+// Health serves the health, readiness and version endpoints. They are served
+// outside of the app snapshots, so they answer before the first generation
+// published one
+type Health struct {
+	live *Live
+
+	mu sync.Mutex
+	// why the initial git sync failed, the site cannot be served until it succeeds
+	syncErr error
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type VersionInfo struct {
+	Version string `json:"version"`
+	// commit of the content repository the live build was generated from
+	ContentCommit       string                        `json:"content_commit,omitempty"`
+	BuildID             string                        `json:"build_id,omitempty"`
+	LastBuildAt         *time.Time                    `json:"last_build_at,omitempty"`
+	HTMLCompilationMode constants.HTMLCompilationMode `json:"html_compilation_mode,omitempty"`
+}
+
+// NewHealth returns the Health of the snapshots published to live
+func NewHealth(live *Live) *Health {
+	return &Health{live: live}
+}
+
+// SetSyncError records that the initial git sync failed, nil once it succeeded
+func (h *Health) SetSyncError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.syncErr = err
+}
+
+func (h *Health) syncError() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.syncErr
+}
+
+// HandleHealthz serves GET /healthz, the liveness probe. It passes while the
+// process is up, a failing initial git sync is retried without a restart
+func (h *Health) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, http.StatusOK, "ok", "")
+}
+
+// HandleReadyz serves GET /readyz, the readiness probe. It passes once the
+// first generation is live with its templates loaded, and fails while the
+// initial git sync has failed
+func (h *Health) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := h.syncError(); err != nil {
+		writeHealthResponse(w, http.StatusServiceUnavailable, "unavailable", err.Error())
+		return
+	}
+
+	app := h.live.App()
+	if app == nil {
+		writeHealthResponse(w, http.StatusServiceUnavailable, "unavailable", "the first generation is not done")
+		return
+	}
+	if app.Templates == nil {
+		writeHealthResponse(w, http.StatusServiceUnavailable, "unavailable", "the templates are not loaded")
+		return
+	}
+	writeHealthResponse(w, http.StatusOK, "ready", "")
+}
+
+// HandleVersion serves GET /version, the version of the binary and the build
+// being served
+func (h *Health) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	info := VersionInfo{Version: version.Get()}
+	if app := h.live.App(); app != nil {
+		info.HTMLCompilationMode = app.ServerConfig.HTMLCompilationMode
+		if app.Build != nil {
+			info.ContentCommit = app.Build.Commit
+			info.BuildID = app.Build.ID
+			info.LastBuildAt = &app.Build.FinishedAt
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+func writeHealthResponse(w http.ResponseWriter, status int, result string, reason string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(healthResponse{Status: result, Reason: reason})
+}
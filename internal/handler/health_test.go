@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/build"
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+)
+
+func TestHealthReadiness(t *testing.T) {
+	live := NewLive(func(app *App) http.Handler { return http.NotFoundHandler() })
+	health := NewHealth(live)
+
+	status := func(handler http.HandlerFunc, path string) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if got := status(health.HandleHealthz, "/healthz"); got != http.StatusOK {
+		t.Errorf("healthz before the first generation = %d, want %d", got, http.StatusOK)
+	}
+	if got := status(health.HandleReadyz, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz before the first generation = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	health.SetSyncError(errors.New("git sync failed"))
+	if got := status(health.HandleHealthz, "/healthz"); got != http.StatusOK {
+		t.Errorf("healthz after a failed git sync = %d, want %d", got, http.StatusOK)
+	}
+	if got := status(health.HandleReadyz, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz after a failed git sync = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	health.SetSyncError(nil)
+
+	live.Publish(&App{ServerConfig: &config.ServerConfig{}})
+	if got := status(health.HandleReadyz, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz without templates = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	live.Publish(&App{ServerConfig: &config.ServerConfig{}, Templates: template.New("default.html")})
+	if got := status(health.HandleReadyz, "/readyz"); got != http.StatusOK {
+		t.Errorf("readyz after the first generation = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestHealthVersion(t *testing.T) {
+	live := NewLive(func(app *App) http.Handler { return http.NotFoundHandler() })
+	finishedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	live.Publish(&App{
+		ServerConfig: &config.ServerConfig{HTMLCompilationMode: constants.HTMLCompilationModeStatic},
+		Build:        &build.Build{ID: "20260102T030400.000000Z", Commit: "abc123", FinishedAt: finishedAt},
+	})
+
+	rec := httptest.NewRecorder()
+	NewHealth(live).HandleVersion(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var info VersionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info.Version == "" {
+		t.Error("version is empty")
+	}
+	if info.ContentCommit != "abc123" || info.BuildID != "20260102T030400.000000Z" {
+		t.Errorf("commit, build = %q, %q, want abc123, 20260102T030400.000000Z", info.ContentCommit, info.BuildID)
+	}
+	if info.LastBuildAt == nil || !info.LastBuildAt.Equal(finishedAt) {
+		t.Errorf("last build at = %v, want %v", info.LastBuildAt, finishedAt)
+	}
+	if info.HTMLCompilationMode != constants.HTMLCompilationModeStatic {
+		t.Errorf("compilation mode = %q, want static", info.HTMLCompilationMode)
+	}
+}
@@ -1,8 +1,9 @@
 package handler
 
 import (
+	"context"
 	"html/template"
-	"net/http"
+	"io"
 	"regexp"
 	"strings"
 
@@ -29,24 +30,44 @@ func determineLayout(app *App, pageName string) (string, string) {
 }
 
 func renderCustomLayout(
+	ctx context.Context,
 	app *App,
-	w http.ResponseWriter,
-	layoutFile, pageName, mdPath string,
+	w io.Writer,
+	layoutFile string,
+	entry *htmlcompiler.SiteMapEntry,
 	data *TemplateData,
 ) error {
+	pageName := entry.Path
 	customLayoutName := layoutFile + ".html"
 	app.Logger.Info("Using custom layout: %s", customLayoutName)
 
 	// Handle blog article layout specifics
 	if customLayoutName == blogArticleLayoutName {
-		if err := prepareBlogArticleData(app, pageName, mdPath, data); err != nil {
+		if err := prepareBlogArticleData(ctx, app, entry, data); err != nil {
 			return err
 		}
 	}
 
+	if err := renderNestedLayout(ctx, app, w, customLayoutName, data); err != nil {
+		return err
+	}
+
+	data.PageName = &pageName
+
+	return nil
+}
+
+// renderNestedLayout renders a layout template and nests the result within the default layout
+func renderNestedLayout(
+	ctx context.Context,
+	app *App,
+	w io.Writer,
+	layoutName string,
+	data *TemplateData,
+) error {
 	// Render the custom layout
 	var customLayoutBuf strings.Builder
-	if err := app.Templates.ExecuteTemplate(&customLayoutBuf, customLayoutName, data); err != nil {
+	if err := executeTemplate(ctx, app, &customLayoutBuf, layoutName, data); err != nil {
 		app.Logger.Error("Custom layout execution error: %v", err)
 		return NewPageError(Err500Code, Err500Title, Err500Message)
 	}
@@ -54,20 +75,18 @@ func renderCustomLayout(
 	// Nest within default layout
 	// #nosec G203 -- Content is from Go template execution with trusted template files, not user input
 	data.Content = template.HTML(customLayoutBuf.String())
-	if err := app.Templates.ExecuteTemplate(w, defaultLayoutFile, data); err != nil {
+	if err := executeTemplate(ctx, app, w, defaultLayoutFile, data); err != nil {
 		app.Logger.Error("Template execution error: %v", err)
 		return err
 	}
 
-	data.PageName = &pageName
-
 	return nil
 }
 
-func prepareBlogArticleData(app *App, pageName string, mdPath string, data *TemplateData) error {
+func prepareBlogArticleData(ctx context.Context, app *App, entry *htmlcompiler.SiteMapEntry, data *TemplateData) error {
 	app.Logger.Info("Fetching headers for the blog article layout")
 
-	htmlContent, err := getHTMLContent(app, pageName, mdPath)
+	htmlContent, err := getHTMLContent(ctx, app, entry)
 	if err != nil {
 		return err
 	}
@@ -88,7 +107,7 @@ func prepareBlogArticleData(app *App, pageName string, mdPath string, data *Temp
 	}
 
 	if firstHeader == "" {
-		firstHeader = pageName
+		firstHeader = entry.Path
 	}
 
 	data.PageName = &firstHeader
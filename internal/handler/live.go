@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Live serves requests with the latest published app snapshot. Publishing a
+// snapshot swaps it in atomically: requests in flight finish with the
+// snapshot they started with
+type Live struct {
+	current atomic.Pointer[liveSnapshot]
+	// the snapshot replaced by the last Publish, requests in flight may still use it
+	previous   atomic.Pointer[liveSnapshot]
+	newHandler func(app *App) http.Handler
+}
+
+type liveSnapshot struct {
+	app     *App
+	handler http.Handler
+}
+
+// NewLive returns a Live building the handler of each snapshot with newHandler
+func NewLive(newHandler func(app *App) http.Handler) *Live {
+	return &Live{newHandler: newHandler}
+}
+
+// Publish makes app the snapshot serving new requests
+func (l *Live) Publish(app *App) {
+	previous := l.current.Swap(&liveSnapshot{app: app, handler: l.newHandler(app)})
+	l.previous.Store(previous)
+}
+
+// App returns the published snapshot, nil before the first Publish
+func (l *Live) App() *App {
+	snapshot := l.current.Load()
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.app
+}
+
+// Previous returns the snapshot replaced by the last Publish, which requests
+// started before it may still be served with. nil before the second Publish
+func (l *Live) Previous() *App {
+	snapshot := l.previous.Load()
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.app
+}
+
+func (l *Live) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := l.current.Load()
+	if snapshot == nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	snapshot.handler.ServeHTTP(w, r)
+}
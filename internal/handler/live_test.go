@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+func TestLivePublish(t *testing.T) {
+	live := NewLive(func(app *App) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, app.SiteConfig.Site.Name)
+		})
+	})
+
+	serve := func() (int, string) {
+		recorder := httptest.NewRecorder()
+		live.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	if code, _ := serve(); code != http.StatusServiceUnavailable {
+		t.Errorf("status before publishing = %d, want 503", code)
+	}
+
+	first := &App{SiteConfig: &config.SiteConfig{Site: config.Site{Name: "first"}}}
+	live.Publish(first)
+	if _, body := serve(); body != "first" {
+		t.Errorf("body = %q, want first", body)
+	}
+	if live.Previous() != nil {
+		t.Errorf("Previous should be nil after the first snapshot")
+	}
+
+	live.Publish(&App{SiteConfig: &config.SiteConfig{Site: config.Site{Name: "second"}}})
+	if _, body := serve(); body != "second" {
+		t.Errorf("body after publishing again = %q, want second", body)
+	}
+	if live.App() == first {
+		t.Errorf("App should return the latest snapshot")
+	}
+	if live.Previous() != first {
+		t.Errorf("Previous should return the replaced snapshot")
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+const llmsTxtRootSection = "Pages"
+
+// HandleLLMsTxt serves /llms.txt, a plain Markdown index of the site for LLM
+// crawlers following https://llmstxt.org
+func HandleLLMsTxt(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !app.SiteConfig.Site.LLMsTxt.Enabled {
+			http.NotFound(w, r)
+			return
+		}
+
+		entries, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Failed to load sitemap: %v", err)
+			http.Error(w, "Failed to load sitemap", http.StatusInternalServerError)
+			return
+		}
+
+		llms := buildLLMsTxt(app.SiteConfig.Site, *entries, getBaseURL(app, r))
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(llms)); err != nil {
+			app.Logger.Error("Failed to write llms.txt: %v", err)
+		}
+	}
+}
+
+func buildLLMsTxt(site config.Site, entries []htmlcompiler.SiteMapEntry, baseURL string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", site.Name)
+	if site.Description != "" {
+		fmt.Fprintf(&b, "\n> %s\n", site.Description)
+	}
+
+	// group pages by their top level directory, section index pages title the group
+	sections := map[string][]htmlcompiler.SiteMapEntry{}
+	sectionTitles := map[string]string{}
+	for _, entry := range entries {
+		if !htmlcompiler.IsIndexable(entry) {
+			continue
+		}
+
+		section := llmsTxtRootSection
+		if before, _, found := strings.Cut(entry.Path, "/"); found {
+			section = before
+		} else if entry.Path != "index" {
+			sectionTitles[entry.Path] = entry.FirstHeader
+		}
+		sections[section] = append(sections[section], entry)
+	}
+
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// top level pages are listed first
+		if names[i] == llmsTxtRootSection || names[j] == llmsTxtRootSection {
+			return names[i] == llmsTxtRootSection
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		title := name
+		if sectionTitles[name] != "" {
+			title = sectionTitles[name]
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+
+		for _, entry := range sections[name] {
+			pageTitle := entry.FirstHeader
+			if pageTitle == "" {
+				pageTitle = entry.Path
+			}
+
+			description := entry.FirstParagraph
+			if entry.Metadata != nil && entry.Metadata.Description != "" {
+				description = entry.Metadata.Description
+			}
+
+			fmt.Fprintf(&b, "- [%s](%s%s)", pageTitle, baseURL, htmlcompiler.EscapeURLPath(htmlcompiler.GetURLPath(entry)))
+			if description != "" {
+				fmt.Fprintf(&b, ": %s", strings.Join(strings.Fields(description), " "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
@@ -4,8 +4,8 @@ import (
 	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
 )
 
-func applyLayoutFilter(app *App, layoutFilter, sitemapPath string, data *TemplateData) error {
-	siteMap, err := htmlcompiler.LoadSiteMap(sitemapPath)
+func applyLayoutFilter(app *App, layoutFilter string, data *TemplateData) error {
+	siteMap, err := app.LoadSiteMap()
 	if err != nil {
 		app.Logger.Error("Error loading site map: %v", err)
 		return NewPageError(Err500Code, Err500Title, Err500Message)
@@ -20,3 +20,28 @@ func applyLayoutFilter(app *App, layoutFilter, sitemapPath string, data *Templat
 	data.PageList = siteMap
 	return nil
 }
+
+// applyPageRelations sets the related pages, previous/next pages, series and
+// breadcrumbs of a page from the site map of the snapshot, which is not modified
+func applyPageRelations(app *App, sitemapEntity *htmlcompiler.SiteMapEntry, data *TemplateData) {
+	related := make([]htmlcompiler.SiteMapEntry, 0, len(sitemapEntity.Related))
+	for _, path := range sitemapEntity.Related {
+		if page, ok := app.pages[path]; ok {
+			related = append(related, page)
+		}
+	}
+	data.Related = related
+
+	data.Prev, data.Next = htmlcompiler.GetPrevNext(app.SiteMap, *sitemapEntity)
+	data.Breadcrumbs = htmlcompiler.GetBreadcrumbs(app.pages, *sitemapEntity)
+
+	if sitemapEntity.Metadata != nil && sitemapEntity.Metadata.Series != "" {
+		data.Series = htmlcompiler.GetSeries(app.SiteMap, sitemapEntity.Metadata.Series)
+		for i, page := range data.Series {
+			if page.Path == sitemapEntity.Path {
+				data.SeriesPosition = i + 1
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+// applyNavigation sets the generated navigation tree of the app in the template data
+// and merges its sections into the navbar when configured
+func applyNavigation(app *App, data *TemplateData) {
+	navigationConfig := app.SiteConfig.Site.Navigation
+	if !navigationConfig.Enabled {
+		return
+	}
+
+	data.Navigation = app.Navigation
+	if navigationConfig.MergeNavbar {
+		data.Navbar = mergeNavbar(app.SiteConfig.Navbar, app.Navigation)
+	}
+}
+
+// mergeNavbar appends the top level sections of the navigation tree to the
+// navbar as dropdowns, skipping sections whose URL is already in the navbar
+func mergeNavbar(navbar []config.NavbarItem, navigation []htmlcompiler.NavNode) []config.NavbarItem {
+	merged := make([]config.NavbarItem, len(navbar), len(navbar)+len(navigation))
+	copy(merged, navbar)
+
+	existing := map[string]bool{}
+	for _, item := range navbar {
+		existing[normalizeNavURL(item.URL)] = true
+	}
+
+	for _, section := range navigation {
+		if len(section.Children) == 0 {
+			continue
+		}
+
+		item := config.NavbarItem{Label: section.Title, URL: section.URL}
+		for _, child := range section.Children {
+			url := child.URL
+			if url == "" {
+				url = firstNavURL(child.Children)
+			}
+			if url == "" {
+				continue
+			}
+			item.Dropdown = append(item.Dropdown, config.Link{Label: child.Title, URL: url})
+		}
+
+		// directories without an index page link to their first page
+		if item.URL == "" {
+			item.URL = firstNavURL(section.Children)
+		}
+		if item.URL == "" || existing[normalizeNavURL(item.URL)] {
+			continue
+		}
+		existing[normalizeNavURL(item.URL)] = true
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+func firstNavURL(nodes []htmlcompiler.NavNode) string {
+	for _, node := range nodes {
+		if node.URL != "" {
+			return node.URL
+		}
+		if url := firstNavURL(node.Children); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+func normalizeNavURL(url string) string {
+	if url == "/" {
+		return url
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// handleRedirect answers a request for a missing page with the matching page
+// alias or site config redirect. It reports whether the request was handled
+func handleRedirect(app *App, w http.ResponseWriter, r *http.Request, data *TemplateData) bool {
+	redirect, ok := app.Redirects.Resolve(r.URL.Path)
+	if !ok {
+		return false
+	}
+
+	if redirect.Status == http.StatusGone {
+		app.Logger.Info("410 Gone: %s", r.URL.Path)
+		data.ErrorCode = &Err410Code
+		data.ErrorTitle = &Err410Title
+		data.ErrorMessage = &Err410Message
+		w.WriteHeader(http.StatusGone)
+		if err := executeTemplate(r.Context(), app, w, "error.html", data); err != nil {
+			app.Logger.Error("Failed to execute template: %v", err)
+		}
+		return true
+	}
+
+	target := redirect.To
+	if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + r.URL.RawQuery
+	}
+
+	app.Logger.Info("%d Redirect: %s -> %s", redirect.Status, r.URL.Path, target)
+	http.Redirect(w, r, target, redirect.Status)
+	return true
+}
+
+// isNotFound reports whether a page error is a 404
+func isNotFound(err error) bool {
+	pageErr := &PageError{}
+	return errors.As(err, &pageErr) && pageErr.Code == Err404Code
+}
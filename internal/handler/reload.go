@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReloadEventsPath is the Server-Sent Events endpoint of the serve --watch mode
+const ReloadEventsPath = "/_mdserve/reload"
+
+// reloadScript reloads the page when the server sends a reload event
+var reloadScript = []byte(`<script>new EventSource("` + ReloadEventsPath +
+	`").addEventListener("reload", function () { location.reload(); });</script>`)
+
+// Reloader pushes reload events to the browsers viewing the site in the
+// serve --watch mode, after each rebuild
+type Reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// NewReloader returns a Reloader without clients
+func NewReloader() *Reloader {
+	return &Reloader{clients: make(map[chan struct{}]struct{})}
+}
+
+// Reload sends a reload event to every connected browser
+func (rl *Reloader) Reload() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for client := range rl.clients {
+		// a client with a reload already pending does not need another one
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (rl *Reloader) subscribe() chan struct{} {
+	client := make(chan struct{}, 1)
+	rl.mu.Lock()
+	rl.clients[client] = struct{}{}
+	rl.mu.Unlock()
+	return client
+}
+
+func (rl *Reloader) unsubscribe(client chan struct{}) {
+	rl.mu.Lock()
+	delete(rl.clients, client)
+	rl.mu.Unlock()
+}
+
+// HandleEvents streams reload events to a browser until it disconnects
+func (rl *Reloader) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+	// the stream outlives the write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := rl.subscribe()
+	defer rl.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// InjectScript adds the reload script to the HTML responses of next and
+// disables caching, so reloads pick up changed assets too
+func (rl *Reloader) InjectScript(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &reloadResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+// reloadResponseWriter buffers HTML responses to insert the reload script
+// before the closing body tag, other responses are written through, as are
+// encoded ones, ex. the precompressed HTML files of user-static
+type reloadResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	html        bool
+	body        bytes.Buffer
+}
+
+func (rw *reloadResponseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = status
+
+	header := rw.Header()
+	header.Set("Cache-Control", "no-store")
+	rw.html = strings.HasPrefix(header.Get("Content-Type"), "text/html") &&
+		header.Get("Content-Encoding") == "" &&
+		status != http.StatusNotModified && status != http.StatusNoContent
+	if !rw.html {
+		rw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rw *reloadResponseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		if rw.Header().Get("Content-Type") == "" {
+			rw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.html {
+		return rw.body.Write(p)
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// finish writes the buffered HTML response with the reload script
+func (rw *reloadResponseWriter) finish() {
+	if !rw.html {
+		return
+	}
+
+	body := rw.body.Bytes()
+	if i := bytes.LastIndex(body, []byte("</body>")); i >= 0 {
+		body = append(body[:i:i], append(reloadScript, body[i:]...)...)
+	} else {
+		body = append(body, reloadScript...)
+	}
+
+	rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	rw.ResponseWriter.WriteHeader(rw.status)
+	_, _ = rw.ResponseWriter.Write(body)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReloaderInjectScript(t *testing.T) {
+	reloader := NewReloader()
+	handler := reloader.InjectScript(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		if r.URL.Path == "/compressed.html" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = io.WriteString(w, "\x1f\x8bcompressed")
+			return
+		}
+		if r.URL.Path == "/style.css" {
+			w.Header().Set("Content-Type", "text/css")
+			_, _ = io.WriteString(w, "body {}")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html><body><h1>Page</h1>")
+		_, _ = io.WriteString(w, "</body></html>")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	want := "<html><body><h1>Page</h1>" + string(reloadScript) + "</body></html>"
+	if body := recorder.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cacheControl)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+	if body := recorder.Body.String(); body != "body {}" {
+		t.Errorf("body of a non HTML response = %q, want it unchanged", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/compressed.html", nil))
+	if body := recorder.Body.String(); body != "\x1f\x8bcompressed" {
+		t.Errorf("body of an encoded HTML response = %q, want it unchanged", body)
+	}
+}
+
+func TestReloaderHandleEvents(t *testing.T) {
+	reloader := NewReloader()
+	server := httptest.NewServer(http.HandlerFunc(reloader.HandleEvents))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", contentType)
+	}
+
+	// the headers are flushed once the client is subscribed
+	reloader.Reload()
+
+	event := make([]byte, 64)
+	n, err := response.Body.Read(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(event[:n]), "event: reload\n") {
+		t.Errorf("event = %q, want a reload event", event[:n])
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+const robotsAllUserAgents = "*"
+
+// HandleRobots serves /robots.txt generated from the site config and the
+// noindex metadata of each page
+func HandleRobots(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := app.LoadSiteMap()
+		if err != nil {
+			app.Logger.Error("Failed to load sitemap: %v", err)
+			http.Error(w, "Failed to load sitemap", http.StatusInternalServerError)
+			return
+		}
+
+		robots := buildRobotsTxt(app.SiteConfig.Site, *entries, getBaseURL(app, r))
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(robots)); err != nil {
+			app.Logger.Error("Failed to write robots.txt: %v", err)
+		}
+	}
+}
+
+func buildRobotsTxt(site config.Site, entries []htmlcompiler.SiteMapEntry, baseURL string) string {
+	var b strings.Builder
+
+	if !site.AllowSearchEngineIndexing {
+		b.WriteString("User-agent: *\nDisallow: /\n")
+		return b.String()
+	}
+
+	// paths disallowed for every crawler, crawlers only obey the most specific
+	// group that matches them so these are repeated in each group
+	disallowed := slices.Clone(site.Robots.Disallow)
+	for _, entry := range entries {
+		if !htmlcompiler.IsIndexable(entry) {
+			disallowed = append(disallowed, htmlcompiler.EscapeURLPath(htmlcompiler.GetURLPath(entry)))
+		}
+	}
+
+	rules := site.Robots.Rules
+	hasWildcard := slices.ContainsFunc(rules, func(rule config.RobotsRule) bool {
+		return strings.TrimSpace(rule.UserAgent) == robotsAllUserAgents
+	})
+	if !hasWildcard {
+		rules = append([]config.RobotsRule{{UserAgent: robotsAllUserAgents}}, rules...)
+	}
+
+	for i, rule := range rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		userAgent := strings.TrimSpace(rule.UserAgent)
+		if userAgent == "" {
+			userAgent = robotsAllUserAgents
+		}
+		fmt.Fprintf(&b, "User-agent: %s\n", userAgent)
+
+		for _, path := range rule.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", path)
+		}
+
+		ruleDisallowed := append(slices.Clone(rule.Disallow), disallowed...)
+		if len(rule.Allow) == 0 && len(ruleDisallowed) == 0 {
+			// an empty Disallow allows everything
+			b.WriteString("Disallow:\n")
+		}
+		for _, path := range ruleDisallowed {
+			fmt.Fprintf(&b, "Disallow: %s\n", path)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", baseURL)
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+)
+
+func TestBuildRobotsTxt(t *testing.T) {
+	entries := []htmlcompiler.SiteMapEntry{
+		{Path: "index"},
+		{Path: "drafts/secret", Metadata: &htmlcompiler.Metadata{NoIndex: true}},
+	}
+
+	tests := []struct {
+		name             string
+		site             config.Site
+		shouldContain    []string
+		shouldNotContain []string
+	}{
+		{
+			name: "Indexing disabled",
+			site: config.Site{AllowSearchEngineIndexing: false},
+			shouldContain: []string{
+				"User-agent: *\nDisallow: /\n",
+			},
+			shouldNotContain: []string{
+				"Sitemap:",
+			},
+		},
+		{
+			name: "Noindex pages and sitemap",
+			site: config.Site{AllowSearchEngineIndexing: true},
+			shouldContain: []string{
+				"User-agent: *\n",
+				"Disallow: /drafts/secret\n",
+				"Sitemap: https://example.com/sitemap.xml\n",
+			},
+		},
+		{
+			name: "Per user agent rules repeat global disallows",
+			site: config.Site{
+				AllowSearchEngineIndexing: true,
+				Robots: config.Robots{
+					Disallow: []string{"/private/*"},
+					Rules: []config.RobotsRule{
+						{UserAgent: "GPTBot", Disallow: []string{"/"}},
+					},
+				},
+			},
+			shouldContain: []string{
+				"User-agent: *\nDisallow: /private/*\nDisallow: /drafts/secret\n",
+				"User-agent: GPTBot\nDisallow: /\nDisallow: /private/*\nDisallow: /drafts/secret\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := buildRobotsTxt(tt.site, entries, "https://example.com")
+
+			for _, expected := range tt.shouldContain {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected output to contain %q, but it didn't.\nGot:\n%s", expected, result)
+				}
+			}
+
+			for _, notExpected := range tt.shouldNotContain {
+				if strings.Contains(result, notExpected) {
+					t.Errorf("Expected output NOT to contain %q, but it did.\nGot:\n%s", notExpected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildLLMsTxt(t *testing.T) {
+	site := config.Site{Name: "MDServe", Description: "Markdown server"}
+	entries := []htmlcompiler.SiteMapEntry{
+		{Path: "index", FirstHeader: "Home"},
+		{Path: "blog", FirstHeader: "The Blog"},
+		{Path: "blog/post", FirstHeader: "A Post", FirstParagraph: "First paragraph"},
+		{Path: "blog/hidden", FirstHeader: "Hidden", Metadata: &htmlcompiler.Metadata{NoIndex: true}},
+	}
+
+	result := buildLLMsTxt(site, entries, "https://example.com")
+
+	expected := []string{
+		"# MDServe\n\n> Markdown server\n",
+		"## Pages\n\n- [Home](https://example.com/)\n- [The Blog](https://example.com/blog)\n",
+		"## The Blog\n\n- [A Post](https://example.com/blog/post): First paragraph\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected output to contain %q, but it didn't.\nGot:\n%s", e, result)
+		}
+	}
+
+	if strings.Contains(result, "Hidden") {
+		t.Errorf("Expected noindex page to be left out.\nGot:\n%s", result)
+	}
+}
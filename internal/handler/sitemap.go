@@ -4,95 +4,250 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net/http"
-	"path/filepath"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/jaysongiroux/mdserve/internal/constants"
 	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
 )
 
+const (
+	sitemapXmlns      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapImageXmlns = "http://www.google.com/schemas/sitemap-image/1.1"
+	// maximum number of URLs allowed in a single sitemap file by the sitemap protocol
+	maxSitemapURLs = 50000
+
+	defaultSitemapChangeFreq = "weekly"
+	defaultSitemapPriority   = 0.5
+)
+
+var validSitemapChangeFreqs = []string{
+	"always", "hourly", "daily", "weekly", "monthly", "yearly", "never",
+}
+
 type SitemapXML struct {
-	XMLName xml.Name `xml:"urlset"`
-	Xmlns   string   `xml:"xmlns,attr"`
-	URLs    []URLXML `xml:"url"`
+	XMLName    xml.Name `xml:"urlset"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	XmlnsImage string   `xml:"xmlns:image,attr"`
+	URLs       []URLXML `xml:"url"`
 }
 
 type URLXML struct {
-	Loc        string `xml:"loc"`
-	LastMod    string `xml:"lastmod"`
-	ChangeFreq string `xml:"changefreq,omitempty"`
-	Priority   string `xml:"priority,omitempty"`
+	Loc        string     `xml:"loc"`
+	LastMod    string     `xml:"lastmod"`
+	ChangeFreq string     `xml:"changefreq,omitempty"`
+	Priority   string     `xml:"priority,omitempty"`
+	Images     []ImageXML `xml:"image:image"`
+}
+
+type ImageXML struct {
+	Loc string `xml:"image:loc"`
+}
+
+type SitemapIndexXML struct {
+	XMLName  xml.Name          `xml:"sitemapindex"`
+	Xmlns    string            `xml:"xmlns,attr"`
+	Sitemaps []SitemapIndexURL `xml:"sitemap"`
 }
 
+type SitemapIndexURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// HandleSitemap serves /sitemap.xml. Sites with more than maxSitemapURLs pages
+// get a sitemap index pointing at /sitemap/<n>.xml instead
 func HandleSitemap(app *App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sitemapPath := filepath.Join(app.ServerConfig.GeneratedPath, constants.SiteMapPath)
-		entries, err := htmlcompiler.LoadSiteMap(sitemapPath)
+		baseURL := getBaseURL(app, r)
+		urls, err := buildSitemapURLs(app, baseURL)
 		if err != nil {
 			app.Logger.Error("Failed to load sitemap: %v", err)
 			http.Error(w, "Failed to load sitemap", http.StatusInternalServerError)
 			return
 		}
 
-		scheme := "http"
-		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-			scheme = "https"
+		if len(urls) <= maxSitemapURLs {
+			writeXML(app, w, SitemapXML{
+				Xmlns:      sitemapXmlns,
+				XmlnsImage: sitemapImageXmlns,
+				URLs:       urls,
+			})
+			return
 		}
-		host := r.Host
-		baseURL := fmt.Sprintf("%s://%s", scheme, host)
-
-		// Check if BaseURL is configured in SiteConfig (if we added it, but we didn't yet)
-		// For now, rely on request host which is common for simple servers.
-
-		var urls []URLXML
-		for _, entry := range *entries {
-			// Ensure path starts with /
-			path := entry.Path
-			if !strings.HasPrefix(path, "/") {
-				path = "/" + path
-			}
-
-			// Construct full URL
-			loc := baseURL + path
-
-			// Handle root path empty check or similar if needed, but usually path is like "about" or "blog/..."
-			// If path is "index", it might be root. Logic in htmlcompiler usually handles index.
-			// Let's assume entry.Path is correct relative path.
-
-			lastMod := entry.LastModifiedDate.Format(time.RFC3339)
-			if entry.LastModifiedDate.IsZero() {
-				lastMod = entry.CreationDate.Format(time.RFC3339)
-			}
-			if lastMod == "0001-01-01T00:00:00Z" {
-				// Fallback to now if totally missing? Or skip?
-				// Let's use current time or omit? Required field usually.
-				lastMod = time.Now().Format(time.RFC3339)
-			}
-
-			urls = append(urls, URLXML{
-				Loc:     loc,
-				LastMod: lastMod,
-				// ChangeFreq and Priority could be inferred or hardcoded
-				ChangeFreq: "weekly",
-				Priority:   "0.5",
+
+		var sitemaps []SitemapIndexURL
+		for i := 0; i*maxSitemapURLs < len(urls); i++ {
+			chunk := urls[i*maxSitemapURLs : min((i+1)*maxSitemapURLs, len(urls))]
+			sitemaps = append(sitemaps, SitemapIndexURL{
+				Loc:     fmt.Sprintf("%s/sitemap/%d.xml", baseURL, i+1),
+				LastMod: latestLastMod(chunk),
 			})
 		}
 
-		sitemap := SitemapXML{
-			Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
-			URLs:  urls,
+		writeXML(app, w, SitemapIndexXML{
+			Xmlns:    sitemapXmlns,
+			Sitemaps: sitemaps,
+		})
+	}
+}
+
+// HandleSitemapPage serves a single /sitemap/<n>.xml file referenced by the sitemap index
+func HandleSitemapPage(app *App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("file"), ".xml"))
+		if err != nil || page < 1 || !strings.HasSuffix(r.PathValue("file"), ".xml") {
+			http.NotFound(w, r)
+			return
 		}
 
-		w.Header().Set("Content-Type", "application/xml")
-		// Cache control is handled by middleware, but we can ensure it here too or let middleware handle it.
-		// Middleware checks extension .xml so it should be fine.
+		urls, err := buildSitemapURLs(app, getBaseURL(app, r))
+		if err != nil {
+			app.Logger.Error("Failed to load sitemap: %v", err)
+			http.Error(w, "Failed to load sitemap", http.StatusInternalServerError)
+			return
+		}
 
-		encoder := xml.NewEncoder(w)
-		encoder.Indent("", "  ")
-		if err := encoder.Encode(sitemap); err != nil {
-			app.Logger.Error("Failed to encode sitemap: %v", err)
+		start := (page - 1) * maxSitemapURLs
+		if start >= len(urls) {
+			http.NotFound(w, r)
 			return
 		}
+
+		writeXML(app, w, SitemapXML{
+			Xmlns:      sitemapXmlns,
+			XmlnsImage: sitemapImageXmlns,
+			URLs:       urls[start:min(start+maxSitemapURLs, len(urls))],
+		})
+	}
+}
+
+// getBaseURL returns the configured canonical base URL, falling back to the
+// scheme and host of the incoming request
+func getBaseURL(app *App, r *http.Request) string {
+	if app.SiteConfig.Site.BaseURL != "" {
+		return strings.TrimSuffix(app.SiteConfig.Site.BaseURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+func buildSitemapURLs(app *App, baseURL string) ([]URLXML, error) {
+	entries, err := app.LoadSiteMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []URLXML
+	for _, entry := range *entries {
+		if !htmlcompiler.IsIndexable(entry) {
+			continue
+		}
+
+		loc := baseURL + htmlcompiler.EscapeURLPath(htmlcompiler.GetURLPath(entry))
+
+		lastMod := htmlcompiler.GetModifiedDate(entry)
+		if lastMod.IsZero() {
+			lastMod = htmlcompiler.GetCreationDate(entry)
+		}
+		if lastMod.IsZero() {
+			lastMod = time.Now()
+		}
+
+		urls = append(urls, URLXML{
+			Loc:        loc,
+			LastMod:    lastMod.Format(time.RFC3339),
+			ChangeFreq: getSitemapChangeFreq(app, entry),
+			Priority:   getSitemapPriority(app, entry),
+			Images:     getSitemapImages(loc, entry.Images),
+		})
+	}
+
+	return urls, nil
+}
+
+func getSitemapChangeFreq(app *App, entry htmlcompiler.SiteMapEntry) string {
+	if entry.Metadata == nil || entry.Metadata.ChangeFreq == "" {
+		return defaultSitemapChangeFreq
+	}
+
+	changeFreq := strings.ToLower(entry.Metadata.ChangeFreq)
+	for _, valid := range validSitemapChangeFreqs {
+		if changeFreq == valid {
+			return changeFreq
+		}
+	}
+
+	app.Logger.Warn("Invalid changefreq %q for page %s, using default", changeFreq, entry.Path)
+	return defaultSitemapChangeFreq
+}
+
+func getSitemapPriority(app *App, entry htmlcompiler.SiteMapEntry) string {
+	priority := defaultSitemapPriority
+	if entry.Metadata != nil && entry.Metadata.Priority != nil {
+		if *entry.Metadata.Priority < 0 || *entry.Metadata.Priority > 1 {
+			app.Logger.Warn(
+				"Invalid priority %v for page %s, using default",
+				*entry.Metadata.Priority,
+				entry.Path,
+			)
+		} else {
+			priority = *entry.Metadata.Priority
+		}
+	}
+	return strconv.FormatFloat(priority, 'f', 1, 64)
+}
+
+// getSitemapImages resolves image sources relative to the page URL
+func getSitemapImages(pageURL string, images []string) []ImageXML {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var sitemapImages []ImageXML
+	for _, image := range images {
+		ref, err := url.Parse(image)
+		if err != nil || strings.HasPrefix(image, "data:") {
+			continue
+		}
+		sitemapImages = append(sitemapImages, ImageXML{Loc: base.ResolveReference(ref).String()})
+	}
+	return sitemapImages
+}
+
+func latestLastMod(urls []URLXML) string {
+	var latest time.Time
+	for _, u := range urls {
+		lastMod, err := time.Parse(time.RFC3339, u.LastMod)
+		if err == nil && lastMod.After(latest) {
+			latest = lastMod
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.Format(time.RFC3339)
+}
+
+func writeXML(app *App, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	// Cache control is handled by middleware, which treats .xml as static
+
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		app.Logger.Error("Failed to write sitemap: %v", err)
+		return
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		app.Logger.Error("Failed to encode sitemap: %v", err)
+		return
 	}
 }
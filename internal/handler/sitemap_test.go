@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+func newSitemapApp(baseURL string, siteMap []htmlcompiler.SiteMapEntry) *App {
+	return &App{
+		ServerConfig: &config.ServerConfig{},
+		SiteConfig:   &config.SiteConfig{Site: config.Site{BaseURL: baseURL}},
+		Logger:       logger.New("Test", logger.ErrorLevel),
+		SiteMap:      siteMap,
+	}
+}
+
+func TestSitemapChangeFreqAndPriority(t *testing.T) {
+	app := newSitemapApp("", nil)
+	priority := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name           string
+		metadata       *htmlcompiler.Metadata
+		wantChangeFreq string
+		wantPriority   string
+	}{
+		{"No metadata", nil, "weekly", "0.5"},
+		{"Unset", &htmlcompiler.Metadata{}, "weekly", "0.5"},
+		{"Valid", &htmlcompiler.Metadata{ChangeFreq: "daily", Priority: priority(0.8)}, "daily", "0.8"},
+		{"Case insensitive change frequency", &htmlcompiler.Metadata{ChangeFreq: "Monthly"}, "monthly", "0.5"},
+		{"Bounds", &htmlcompiler.Metadata{ChangeFreq: "never", Priority: priority(1)}, "never", "1.0"},
+		{"Zero priority", &htmlcompiler.Metadata{Priority: priority(0)}, "weekly", "0.0"},
+		{"Invalid change frequency", &htmlcompiler.Metadata{ChangeFreq: "fortnightly"}, "weekly", "0.5"},
+		{"Priority above 1", &htmlcompiler.Metadata{Priority: priority(1.5)}, "weekly", "0.5"},
+		{"Negative priority", &htmlcompiler.Metadata{Priority: priority(-0.1)}, "weekly", "0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := htmlcompiler.SiteMapEntry{Path: "blog/post", Metadata: tt.metadata}
+			if got := getSitemapChangeFreq(app, entry); got != tt.wantChangeFreq {
+				t.Errorf("changefreq = %q, want %q", got, tt.wantChangeFreq)
+			}
+			if got := getSitemapPriority(app, entry); got != tt.wantPriority {
+				t.Errorf("priority = %q, want %q", got, tt.wantPriority)
+			}
+		})
+	}
+}
+
+func TestGetBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		headers map[string]string
+		want    string
+	}{
+		{"Configured base URL", "https://example.com/", nil, "https://example.com"},
+		{"Configured base URL wins over the request", "https://example.com", map[string]string{"X-Forwarded-Proto": "http"}, "https://example.com"},
+		{"Request host", "", nil, "http://mdserve.test"},
+		{"Forwarded HTTPS", "", map[string]string{"X-Forwarded-Proto": "https"}, "https://mdserve.test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://mdserve.test/sitemap.xml", nil)
+			for name, value := range tt.headers {
+				request.Header.Set(name, value)
+			}
+			if got := getBaseURL(newSitemapApp(tt.baseURL, nil), request); got != tt.want {
+				t.Errorf("getBaseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleSitemap(t *testing.T) {
+	modified := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	app := newSitemapApp("https://example.com/", []htmlcompiler.SiteMapEntry{
+		{Path: "index", LastModifiedDate: modified},
+		{Path: "blog/post", LastModifiedDate: modified, Images: []string{
+			"cover.png",
+			"/assets/banner.webp",
+			"https://cdn.example.org/photo.jpg",
+			"data:image/png;base64,AAAA",
+		}},
+		{Path: "drafts/secret", LastModifiedDate: modified, Metadata: &htmlcompiler.Metadata{NoIndex: true}},
+	})
+
+	// encoding/xml cannot read the prefixed image elements back, so the URLs are
+	// checked before they are encoded
+	request := httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil)
+	urls, err := buildSitemapURLs(app, getBaseURL(app, request))
+	if err != nil {
+		t.Fatalf("buildSitemapURLs() error = %v", err)
+	}
+
+	tests := []struct {
+		loc        string
+		wantImages []string
+	}{
+		{"https://example.com/", nil},
+		{"https://example.com/blog/post", []string{
+			"https://example.com/blog/cover.png",
+			"https://example.com/assets/banner.webp",
+			"https://cdn.example.org/photo.jpg",
+		}},
+	}
+
+	if len(urls) != len(tests) {
+		t.Fatalf("sitemap has %d URLs, want %d without the noindex page", len(urls), len(tests))
+	}
+	for i, tt := range tests {
+		url := urls[i]
+		if url.Loc != tt.loc {
+			t.Errorf("URL %d = %q, want %q", i, url.Loc, tt.loc)
+		}
+		if url.LastMod != modified.Format(time.RFC3339) {
+			t.Errorf("lastmod of %s = %q, want %q", url.Loc, url.LastMod, modified.Format(time.RFC3339))
+		}
+		var images []string
+		for _, image := range url.Images {
+			images = append(images, image.Loc)
+		}
+		if !slices.Equal(images, tt.wantImages) {
+			t.Errorf("images of %s = %q, want %q", url.Loc, images, tt.wantImages)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	HandleSitemap(app)(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if !strings.Contains(recorder.Body.String(), "<image:loc>https://example.com/blog/cover.png</image:loc>") {
+		t.Errorf("the sitemap does not list the images of the pages:\n%s", recorder.Body.String())
+	}
+	if strings.Contains(recorder.Body.String(), "drafts/secret") {
+		t.Errorf("the sitemap lists a noindex page:\n%s", recorder.Body.String())
+	}
+}
+
+func TestHandleSitemapIndex(t *testing.T) {
+	siteMap := make([]htmlcompiler.SiteMapEntry, maxSitemapURLs+1)
+	for i := range siteMap {
+		siteMap[i] = htmlcompiler.SiteMapEntry{Path: fmt.Sprintf("pages/%d", i)}
+	}
+	app := newSitemapApp("https://example.com", siteMap)
+
+	recorder := httptest.NewRecorder()
+	HandleSitemap(app)(recorder, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	var index SitemapIndexXML
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &index); err != nil {
+		t.Fatalf("invalid sitemap index: %v", err)
+	}
+	var locs []string
+	for _, sitemap := range index.Sitemaps {
+		locs = append(locs, sitemap.Loc)
+	}
+	wantLocs := []string{"https://example.com/sitemap/1.xml", "https://example.com/sitemap/2.xml"}
+	if !slices.Equal(locs, wantLocs) {
+		t.Fatalf("sitemap index = %q, want %q", locs, wantLocs)
+	}
+
+	tests := []struct {
+		file       string
+		wantStatus int
+		wantURLs   int
+	}{
+		{"1.xml", http.StatusOK, maxSitemapURLs},
+		{"2.xml", http.StatusOK, 1},
+		{"3.xml", http.StatusNotFound, 0},
+		{"0.xml", http.StatusNotFound, 0},
+		{"abc.xml", http.StatusNotFound, 0},
+		{"1.txt", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/sitemap/"+tt.file, nil)
+			request.SetPathValue("file", tt.file)
+			recorder := httptest.NewRecorder()
+			HandleSitemapPage(app)(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var sitemap SitemapXML
+			if err := xml.Unmarshal(recorder.Body.Bytes(), &sitemap); err != nil {
+				t.Fatalf("invalid sitemap: %v", err)
+			}
+			if len(sitemap.URLs) != tt.wantURLs {
+				t.Errorf("sitemap has %d URLs, want %d", len(sitemap.URLs), tt.wantURLs)
+			}
+		})
+	}
+}
@@ -26,10 +26,28 @@ type TemplateData struct {
 	PageList       *[]htmlcompiler.SiteMapEntry
 	Metadata       *htmlcompiler.Metadata
 	SiteMapEntity  *htmlcompiler.SiteMapEntry
+	Related        []htmlcompiler.SiteMapEntry
+	Prev           *htmlcompiler.SiteMapEntry
+	Next           *htmlcompiler.SiteMapEntry
+	// ordered pages of the series the page belongs to and its 1-based position
+	Series         []htmlcompiler.SiteMapEntry
+	SeriesPosition int
+	Archive        *ArchiveData
+	// resolved authors of the current page
+	Authors []config.Author
+	// author of the current /authors/<id>/ page
+	AuthorProfile *config.Author
+	// navigation tree of the content directory, see site.navigation
+	Navigation []htmlcompiler.NavNode
+	// trail from the home page to the current page and its BreadcrumbList structured data
+	Breadcrumbs    []htmlcompiler.Breadcrumb
+	BreadcrumbList map[string]any
+	// builds and config of the /admin/ dashboard
+	Admin *AdminData
 }
 
 func newTemplateData(app *App) TemplateData {
-	return TemplateData{
+	data := TemplateData{
 		Site:           app.SiteConfig.Site,
 		Navbar:         app.SiteConfig.Navbar,
 		Footer:         app.SiteConfig.Footer,
@@ -39,4 +57,7 @@ func newTemplateData(app *App) TemplateData {
 		AssetsPath:     "/" + app.ServerConfig.AssetsPath,
 		SiteMapEntity:  nil,
 	}
+	applyNavigation(app, &data)
+
+	return data
 }
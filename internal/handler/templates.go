@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
+	"github.com/jaysongiroux/mdserve/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const layoutTemplatesDirectory = "layout_templates"
+
+var tocHrefPattern = regexp.MustCompile(`[^a-zA-Z0-9 \-]`)
+
+// templateFuncs are the functions available to every template
+var templateFuncs = template.FuncMap{
+	"table_of_contents_href": func(text string) string {
+		// lower and replace all non-alphanumeric characters (except spaces and hyphens) with ""
+		cleanedText := tocHrefPattern.ReplaceAllString(strings.TrimSpace(text), "")
+		// replace spaces with hyphens
+		cleanedText = strings.ReplaceAll(cleanedText, " ", "-")
+		return strings.ToLower(cleanedText)
+	},
+	"is_last": func(index int, length int) bool {
+		return index == int(length)-1
+	},
+	"array_to_string": func(array []string) string {
+		return strings.Join(array, ", ")
+	},
+	"page_url": htmlcompiler.GetURLPath,
+}
+
+// ParseTemplates parses the templates of templatesPath and its layout_templates subdirectory
+func ParseTemplates(templatesPath string) (*template.Template, error) {
+	templates, err := template.New("").Funcs(templateFuncs).ParseGlob(filepath.Join(templatesPath, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	// load templates from layout_templates subdirectory
+	layoutTemplatesPath := filepath.Join(templatesPath, layoutTemplatesDirectory)
+	if _, err := os.Stat(layoutTemplatesPath); err == nil {
+		templates, err = templates.ParseGlob(filepath.Join(layoutTemplatesPath, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// executeTemplate executes the template name of the app to w in a span
+func executeTemplate(ctx context.Context, app *App, w io.Writer, name string, data any) error {
+	_, span := tracing.Start(ctx, "template.execute", attribute.String("mdserve.template", name))
+	err := app.Templates.ExecuteTemplate(w, name, data)
+	tracing.End(span, err)
+	return err
+}
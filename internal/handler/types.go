@@ -4,17 +4,31 @@ import (
 	"html/template"
 	"net/http"
 
+	"github.com/jaysongiroux/mdserve/internal/build"
 	"github.com/jaysongiroux/mdserve/internal/config"
+	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
 	"github.com/jaysongiroux/mdserve/internal/logger"
 )
 
+// App is an immutable snapshot of everything needed to serve a build of the
+// site. Regenerating the site publishes a new App instead of modifying it, see Live
 type App struct {
-	ServerConfig            *config.ServerConfig
-	SiteConfig              *config.SiteConfig
-	Logger                  *logger.Logger
-	Templates               *template.Template
-	Handler                 func(app *App, w http.ResponseWriter, r *http.Request)
+	ServerConfig *config.ServerConfig
+	SiteConfig   *config.SiteConfig
+	Logger       *logger.Logger
+	Templates    *template.Template
+	Handler      func(app *App, w http.ResponseWriter, r *http.Request)
+	// the build the generated files are served from and its directory
+	Build                   *build.Build
+	BuildPath               string
 	TemplatesGeneratedPath  string
 	AssetsGeneratedPath     string
 	UserStaticGeneratedPath string
+	// site map and navigation tree of the build, use LoadSiteMap for a copy
+	SiteMap    []htmlcompiler.SiteMapEntry
+	Navigation []htmlcompiler.NavNode
+	// the redirects of the page aliases and the site config, see handleRedirect
+	Redirects *htmlcompiler.Redirects
+	// the pages of SiteMap by path, built once by LoadApp
+	pages map[string]htmlcompiler.SiteMapEntry
 }
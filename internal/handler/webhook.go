@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// push payloads of large repositories can reach a few megabytes, GitHub caps them at 25MB
+const maxWebhookPayloadSize = 25 << 20
+
+// gitProvider is the git host that sent a webhook
+type gitProvider string
+
+const (
+	gitProviderGitHub  gitProvider = "github"
+	gitProviderGitLab  gitProvider = "gitlab"
+	gitProviderGitea   gitProvider = "gitea"
+	gitProviderForgejo gitProvider = "forgejo"
+)
+
+var (
+	errWebhookUnauthorized = errors.New("invalid webhook signature or token")
+	errWebhookUnknown      = errors.New("unknown webhook provider")
+)
+
+// gitPushPayload holds the fields of a push event shared by GitHub, GitLab
+// and Gitea/Forgejo
+type gitPushPayload struct {
+	Ref   string `json:"ref"`
+	After string `json:"after"`
+}
+
+type webhookResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// HandleGitWebhook serves POST /hooks/git, the push webhook of the git remote
+// content repository. The signature (GitHub, Gitea, Forgejo) or token (GitLab)
+// of the request is verified against git_webhook_secret, and pushes to
+// git_remote_content_branch trigger a regeneration of the site in the
+// background with regenerate
+func HandleGitWebhook(app *App, regenerate func(callerName string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeAPIError(w, http.StatusRequestEntityTooLarge, "payload too large")
+			return
+		}
+		if err != nil {
+			app.Logger.Warn("Failed to read git webhook payload from %s: %v", r.RemoteAddr, err)
+			writeAPIError(w, http.StatusBadRequest, "failed to read payload")
+			return
+		}
+
+		provider, event, err := verifyGitWebhook(r.Header, body, app.ServerConfig.GitWebhookSecret)
+		if errors.Is(err, errWebhookUnauthorized) {
+			app.Logger.Warn("Rejected git webhook from %s: %v", r.RemoteAddr, err)
+			writeAPIError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !isPushEvent(provider, event) {
+			writeWebhookResponse(w, http.StatusOK, "ignored", "not a push event: "+event)
+			return
+		}
+
+		var payload gitPushPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid push payload: "+err.Error())
+			return
+		}
+
+		branchRef := "refs/heads/" + app.ServerConfig.GitRemoteContentBranch
+		if payload.Ref != branchRef {
+			writeWebhookResponse(w, http.StatusOK, "ignored", "push to "+payload.Ref+", not "+branchRef)
+			return
+		}
+
+		app.Logger.Info("Received %s push of %s to %s, regenerating the site...", provider, payload.After, payload.Ref)
+		regenerate("Git Webhook")
+		writeWebhookResponse(w, http.StatusAccepted, "accepted", "")
+	}
+}
+
+// verifyGitWebhook identifies the provider of a webhook from its headers and
+// verifies it against secret, returning the provider and the event name
+func verifyGitWebhook(header http.Header, body []byte, secret string) (gitProvider, string, error) {
+	switch {
+	// Gitea and Forgejo also send the GitHub headers, so they are checked first
+	case header.Get("X-Forgejo-Event") != "":
+		return gitProviderForgejo, header.Get("X-Forgejo-Event"),
+			verifyHMAC(header.Get("X-Forgejo-Signature"), body, secret)
+	case header.Get("X-Gitea-Event") != "":
+		return gitProviderGitea, header.Get("X-Gitea-Event"),
+			verifyHMAC(header.Get("X-Gitea-Signature"), body, secret)
+	case header.Get("X-Gitlab-Event") != "":
+		token := header.Get("X-Gitlab-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return gitProviderGitLab, "", errWebhookUnauthorized
+		}
+		return gitProviderGitLab, header.Get("X-Gitlab-Event"), nil
+	case header.Get("X-GitHub-Event") != "":
+		signature, found := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		if !found {
+			return gitProviderGitHub, "", errWebhookUnauthorized
+		}
+		return gitProviderGitHub, header.Get("X-GitHub-Event"), verifyHMAC(signature, body, secret)
+	default:
+		return "", "", errWebhookUnknown
+	}
+}
+
+// verifyHMAC checks a hex encoded HMAC-SHA256 signature of body
+func verifyHMAC(signature string, body []byte, secret string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return errWebhookUnauthorized
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errWebhookUnauthorized
+	}
+	return nil
+}
+
+func isPushEvent(provider gitProvider, event string) bool {
+	if provider == gitProviderGitLab {
+		return event == "Push Hook"
+	}
+	return event == "push"
+}
+
+func writeWebhookResponse(w http.ResponseWriter, status int, result string, reason string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(webhookResponse{Status: result, Reason: reason})
+}
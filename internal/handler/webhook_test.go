@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+func TestHandleGitWebhook(t *testing.T) {
+	const secret = "s3cret"
+	app := &App{
+		ServerConfig: &config.ServerConfig{
+			GitRemoteContentURL:    "https://example.com/content.git",
+			GitRemoteContentBranch: "main",
+			GitWebhookSecret:       secret,
+		},
+		Logger: logger.New("Test", logger.ErrorLevel),
+	}
+
+	sign := func(body string, key string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	push := `{"ref": "refs/heads/main", "after": "abc123"}`
+	otherBranch := `{"ref": "refs/heads/feature", "after": "abc123"}`
+
+	tests := []struct {
+		name        string
+		body        string
+		headers     map[string]string
+		wantStatus  int
+		wantTrigger bool
+	}{
+		{"GitHub push", push, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(push, secret)}, http.StatusAccepted, true},
+		{"GitHub wrong secret", push, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(push, "wrong")}, http.StatusUnauthorized, false},
+		{"GitHub unsigned", push, map[string]string{"X-GitHub-Event": "push"}, http.StatusUnauthorized, false},
+		{"GitHub ping", `{}`, map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=" + sign(`{}`, secret)}, http.StatusOK, false},
+		{"GitHub other branch", otherBranch, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(otherBranch, secret)}, http.StatusOK, false},
+		{"GitLab push", push, map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": secret}, http.StatusAccepted, true},
+		{"GitLab wrong token", push, map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "wrong"}, http.StatusUnauthorized, false},
+		{"Gitea push", push, map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(push, secret), "X-GitHub-Event": "push"}, http.StatusAccepted, true},
+		{"Forgejo push", push, map[string]string{"X-Forgejo-Event": "push", "X-Forgejo-Signature": sign(push, secret)}, http.StatusAccepted, true},
+		{"Forgejo tampered body", push, map[string]string{"X-Forgejo-Event": "push", "X-Forgejo-Signature": sign(otherBranch, secret)}, http.StatusUnauthorized, false},
+		{"Unknown provider", push, nil, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triggered := false
+			handler := HandleGitWebhook(app, func(callerName string) {
+				triggered = true
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/hooks/git", strings.NewReader(tt.body))
+			for name, value := range tt.headers {
+				request.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if triggered != tt.wantTrigger {
+				t.Errorf("triggered = %v, want %v", triggered, tt.wantTrigger)
+			}
+		})
+	}
+}
+
+// errReader fails like a connection dropped while reading the body
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestHandleGitWebhookReadErrors(t *testing.T) {
+	app := &App{
+		ServerConfig: &config.ServerConfig{GitRemoteContentBranch: "main", GitWebhookSecret: "s3cret"},
+		Logger:       logger.New("Test", logger.ErrorLevel),
+	}
+
+	tests := []struct {
+		name       string
+		body       io.Reader
+		wantStatus int
+	}{
+		{"Too large", strings.NewReader(strings.Repeat("a", maxWebhookPayloadSize+1)), http.StatusRequestEntityTooLarge},
+		{"Read error", errReader{}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HandleGitWebhook(app, func(callerName string) {
+				t.Error("a payload that could not be read triggered a regeneration")
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/hooks/git", tt.body)
+			request.Header.Set("X-GitHub-Event", "push")
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if strings.Contains(recorder.Body.String(), io.ErrUnexpectedEOF.Error()) {
+				t.Errorf("the read error was echoed: %s", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package htmlcompiler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ArchiveYear struct {
+	Year   int
+	Count  int
+	URL    string
+	Months []ArchiveMonth
+}
+
+type ArchiveMonth struct {
+	Year  int
+	Month time.Month
+	Count int
+	URL   string
+}
+
+// BuildArchive groups pages by the year and month of their creation date,
+// newest period first. Pages without a creation date are left out
+func BuildArchive(siteMap []SiteMapEntry) []ArchiveYear {
+	years := map[int]*ArchiveYear{}
+	months := map[int]map[time.Month]*ArchiveMonth{}
+
+	for _, page := range siteMap {
+		date := GetCreationDate(page)
+		if date.IsZero() {
+			continue
+		}
+
+		year, month := date.Year(), date.Month()
+		if _, ok := years[year]; !ok {
+			years[year] = &ArchiveYear{Year: year, URL: GetArchiveURL(year, 0)}
+			months[year] = map[time.Month]*ArchiveMonth{}
+		}
+		years[year].Count++
+
+		if _, ok := months[year][month]; !ok {
+			months[year][month] = &ArchiveMonth{
+				Year:  year,
+				Month: month,
+				URL:   GetArchiveURL(year, month),
+			}
+		}
+		months[year][month].Count++
+	}
+
+	archive := make([]ArchiveYear, 0, len(years))
+	for year, archiveYear := range years {
+		for _, archiveMonth := range months[year] {
+			archiveYear.Months = append(archiveYear.Months, *archiveMonth)
+		}
+		sort.Slice(archiveYear.Months, func(i, j int) bool {
+			return archiveYear.Months[i].Month > archiveYear.Months[j].Month
+		})
+		archive = append(archive, *archiveYear)
+	}
+	sort.Slice(archive, func(i, j int) bool {
+		return archive[i].Year > archive[j].Year
+	})
+
+	return archive
+}
+
+// FilterSiteMapByDate returns the pages created in a year, or in a month of
+// that year when month is not 0
+func FilterSiteMapByDate(siteMap []SiteMapEntry, year int, month time.Month) []SiteMapEntry {
+	pages := make([]SiteMapEntry, 0)
+	for _, page := range siteMap {
+		date := GetCreationDate(page)
+		if date.IsZero() || date.Year() != year {
+			continue
+		}
+		if month != 0 && date.Month() != month {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// GetArchiveURL returns the URL of the archive page of a year, or of a month
+// of that year when month is not 0
+func GetArchiveURL(year int, month time.Month) string {
+	if month == 0 {
+		return fmt.Sprintf("/archive/%d/", year)
+	}
+	return fmt.Sprintf("/archive/%d/%02d/", year, int(month))
+}
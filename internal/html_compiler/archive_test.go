@@ -0,0 +1,87 @@
+package htmlcompiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildArchive(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	siteMap := []SiteMapEntry{
+		{Path: "a", CreationDate: date(2024, time.March, 1)},
+		{Path: "b", CreationDate: date(2025, time.January, 15)},
+		{Path: "c", CreationDate: date(2024, time.November, 30)},
+		{Path: "d", CreationDate: date(2024, time.March, 20)},
+		// the metadata date wins over the file date
+		{Path: "e", CreationDate: date(2020, time.May, 5), Metadata: &Metadata{CreationDate: date(2025, time.June, 2)}},
+		// pages without a date are not archived
+		{Path: "f"},
+		{Path: "g", Metadata: &Metadata{}},
+	}
+
+	// year (count): month count, ...
+	var got []string
+	for _, year := range BuildArchive(siteMap) {
+		var months []string
+		for _, month := range year.Months {
+			if month.Year != year.Year {
+				t.Errorf("month %s of %d has year %d", month.Month, year.Year, month.Year)
+			}
+			months = append(months, fmt.Sprintf("%s %d %s", month.Month, month.Count, month.URL))
+		}
+		got = append(got, fmt.Sprintf("%d (%d) %s: %s", year.Year, year.Count, year.URL, strings.Join(months, ", ")))
+	}
+
+	want := []string{
+		"2025 (2) /archive/2025/: June 1 /archive/2025/06/, January 1 /archive/2025/01/",
+		"2024 (3) /archive/2024/: November 1 /archive/2024/11/, March 2 /archive/2024/03/",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("BuildArchive() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if archive := BuildArchive([]SiteMapEntry{{Path: "undated"}}); len(archive) != 0 {
+		t.Errorf("BuildArchive() of undated pages = %v, want no years", archive)
+	}
+}
+
+func TestFilterSiteMapByDate(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "a", CreationDate: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{Path: "b", CreationDate: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{Path: "c", CreationDate: time.Date(2024, time.November, 30, 0, 0, 0, 0, time.UTC)},
+		{Path: "undated"},
+	}
+
+	tests := []struct {
+		name  string
+		year  int
+		month time.Month
+		want  string
+	}{
+		{"Year", 2024, 0, "a,c"},
+		{"Month", 2024, time.November, "c"},
+		{"Month without pages", 2024, time.April, ""},
+		{"Year without pages", 2023, 0, ""},
+		// undated pages have the zero time, year 1, and are never matched
+		{"Zero date", 1, 0, ""},
+		{"Zero date month", 1, time.January, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, page := range FilterSiteMapByDate(siteMap, tt.year, tt.month) {
+				paths = append(paths, page.Path)
+			}
+			if got := strings.Join(paths, ","); got != tt.want {
+				t.Errorf("FilterSiteMapByDate(%d, %d) = %q, want %q", tt.year, tt.month, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package htmlcompiler
+
+import (
+	"strings"
+)
+
+// Breadcrumb is a step in the trail from the home page to the current page
+type Breadcrumb struct {
+	Title string
+	// empty for directories without an index page
+	URL     string
+	Current bool
+}
+
+// GetBreadcrumbs returns the trail from the home page to an entry, one step
+// per path segment. Each step is titled by the first header of its page,
+// falling back to the prettified segment when the directory has no index page.
+// pages is the site map by path, see IndexSiteMap
+func GetBreadcrumbs(pages map[string]SiteMapEntry, entry SiteMapEntry) []Breadcrumb {
+	home := Breadcrumb{Title: "Home", URL: "/"}
+	if page, ok := pages["index"]; ok && page.FirstHeader != "" {
+		home.Title = page.FirstHeader
+	}
+	if entry.Path == "index" || entry.Path == "" {
+		home.Current = true
+		return []Breadcrumb{home}
+	}
+
+	breadcrumbs := []Breadcrumb{home}
+	segments := strings.Split(entry.Path, "/")
+	for i, segment := range segments {
+		path := strings.Join(segments[:i+1], "/")
+		breadcrumb := Breadcrumb{Title: PrettifySegment(segment)}
+
+		page, ok := pages[path]
+		if path == entry.Path {
+			page, ok = entry, true
+			breadcrumb.Current = true
+		}
+		if ok {
+			breadcrumb.URL = GetURLPath(page)
+			if page.FirstHeader != "" {
+				breadcrumb.Title = page.FirstHeader
+			}
+		}
+
+		breadcrumbs = append(breadcrumbs, breadcrumb)
+	}
+
+	return breadcrumbs
+}
@@ -0,0 +1,56 @@
+package htmlcompiler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetBreadcrumbs(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "index", FirstHeader: "Welcome"},
+		{Path: "docs", FirstHeader: "Documentation"},
+		{Path: "docs/getting_started/install", FirstHeader: "Installing"},
+	}
+
+	tests := []struct {
+		name     string
+		entry    SiteMapEntry
+		expected []Breadcrumb
+	}{
+		{
+			name:  "Home page",
+			entry: siteMap[0],
+			expected: []Breadcrumb{
+				{Title: "Welcome", URL: "/", Current: true},
+			},
+		},
+		{
+			name:  "Nested page with a directory without index page",
+			entry: siteMap[2],
+			expected: []Breadcrumb{
+				{Title: "Welcome", URL: "/"},
+				{Title: "Documentation", URL: "/docs"},
+				{Title: "Getting started"},
+				{Title: "Installing", URL: "/docs/getting_started/install", Current: true},
+			},
+		},
+		{
+			name:  "Page without a first header",
+			entry: SiteMapEntry{Path: "docs/faq"},
+			expected: []Breadcrumb{
+				{Title: "Welcome", URL: "/"},
+				{Title: "Documentation", URL: "/docs"},
+				{Title: "Faq", URL: "/docs/faq", Current: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetBreadcrumbs(IndexSiteMap(siteMap), tt.entry)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("GetBreadcrumbs() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
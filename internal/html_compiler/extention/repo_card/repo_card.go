@@ -8,6 +8,8 @@
 package repocard
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -149,11 +151,13 @@ func (r *RepoCardHTMLRenderer) renderRepoCard(
 	repoCard := node.(*RepoCardNode)
 
 	if repoCard.Owner == "" || repoCard.Repo == "" {
-		logger.Fatal("Invalid repo card: missing owner or repo name")
+		logger.Error("Invalid repo card: missing owner or repo name")
+		return gast.WalkStop, errors.New("invalid repo card: missing owner or repo name")
 	}
 
 	if !repoNamePattern.MatchString(repoCard.Owner) || !repoNamePattern.MatchString(repoCard.Repo) {
-		logger.Fatal("Invalid repo card format: %s/%s", repoCard.Owner, repoCard.Repo)
+		logger.Error("Invalid repo card format: %s/%s", repoCard.Owner, repoCard.Repo)
+		return gast.WalkStop, fmt.Errorf("invalid repo card format: %s/%s", repoCard.Owner, repoCard.Repo)
 	}
 
 	cardURL := "https://gh-card.dev/repos/" + repoCard.Owner + "/" + repoCard.Repo + ".svg"
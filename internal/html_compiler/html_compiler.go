@@ -2,12 +2,14 @@ package htmlcompiler
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,83 +22,126 @@ import (
 	repocard "github.com/jaysongiroux/mdserve/internal/html_compiler/extention/repo_card"
 	"github.com/jaysongiroux/mdserve/internal/logger"
 	"github.com/jaysongiroux/mdserve/internal/routines"
+	"github.com/jaysongiroux/mdserve/internal/tracing"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
 	"go.abhg.dev/goldmark/mermaid"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
+// compilerLogger logs the messages of the compiler service
+var compilerLogger = logger.Service("compiler")
+
 type SiteMapEntry struct {
-	Path             string    `json:"path"`
+	Path string `json:"path"`
+	// markdown file of the page relative to the content directory
+	SourcePath string `json:"source_path"`
+	// canonical URL path of the page, see GetURLPath
+	URL              string    `json:"url"`
 	Metadata         *Metadata `json:"metadata"`
 	FirstHeader      string    `json:"first_header"`
 	FirstParagraph   string    `json:"first_paragraph"`
 	LastModifiedDate time.Time `json:"last_modified_date"`
 	CreationDate     time.Time `json:"creation_date"`
 	FirstImage       string    `json:"first_image"`
+	Images           []string  `json:"images"`
+	// paths of related pages in the same section, most related first
+	Related []string `json:"related"`
+	// authors of the first and last commit of the page when dates come from git
+	CreatedBy  string `json:"created_by,omitempty"`
+	ModifiedBy string `json:"modified_by,omitempty"`
 }
 
+// CompileHTMLFiles compiles the markdown files to buildPath/html, at their site map paths
 func CompileHTMLFiles(
+	ctx context.Context,
 	mdFiles []string,
 	siteConfig *config.SiteConfig,
 	serverConfig *config.ServerConfig,
+	buildPath string,
 ) error {
 	if len(mdFiles) == 0 {
-		logger.Warn("No MD files to compile, skipping HTML compilation")
+		compilerLogger.Ctx(ctx).Warn("No MD files to compile, skipping HTML compilation")
 		return nil
 	}
 
 	maxWorkers := routines.CalculateMaxWorkers(len(mdFiles))
-	logger.Info("Compiling %d MD files with %d concurrent workers", len(mdFiles), maxWorkers)
+	compilerLogger.Ctx(ctx).Info("Compiling %d MD files with %d concurrent workers", len(mdFiles), maxWorkers)
 
-	g := new(errgroup.Group)
+	// the first failure or a cancelled build stops the remaining workers
+	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxWorkers)
 
 	for i, mdFile := range mdFiles {
 		g.Go(func() error {
-			logger.Info("[Worker %d] Compiling MD file: %s", i, mdFile)
-			htmlFile, err := CompileHTMLFile(mdFile, siteConfig)
-			logger.Debug("[Worker %d] Compiling HTML file: %s", i, mdFile)
-			if err != nil {
-				logger.Fatal("[Worker %d] Failed to compile HTML file: %v", i, err)
-				return fmt.Errorf("failed to compile HTML file: %w", err)
+			if err := ctx.Err(); err != nil {
+				return err
 			}
+			_, span := tracing.Start(ctx, "compile.file", attribute.String("mdserve.file", mdFile))
+			err := compileHTMLFileTo(ctx, i, mdFile, siteConfig, serverConfig, buildPath)
+			tracing.End(span, err)
+			return err
+		})
+	}
 
-			// Calculate relative path from content directory to preserve folder structure
-			relPath, err := filepath.Rel(serverConfig.ContentPath, mdFile)
-			if err != nil {
-				logger.Fatal("[Worker %d] Failed to get relative path for %s: %v", i, mdFile, err)
-				return fmt.Errorf("failed to get relative path for %s: %w", mdFile, err)
-			}
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("failed to compile HTML files: %w", err)
+	}
 
-			// save the HTML file to the compiled HTML path
-			// write files to the content path /.html/ preserving directory structure
-			savePath := filepath.Join(serverConfig.GeneratedPath)
-			logger.Debug("[Worker %d] Writing HTML file: %s to %s", i, relPath, savePath)
+	compilerLogger.Ctx(ctx).Info("Successfully compiled all MD files")
+	return nil
+}
 
-			err = WriteHTMLFile(savePath, relPath, htmlFile)
-			if err != nil {
-				logger.Fatal("[Worker %d] Failed to write HTML file: %v", i, err)
-				return fmt.Errorf("failed to write HTML file: %w", err)
-			}
+// compileHTMLFileTo compiles a markdown file to buildPath/html at its site map
+// path, worker numbers the log lines
+func compileHTMLFileTo(
+	ctx context.Context,
+	worker int,
+	mdFile string,
+	siteConfig *config.SiteConfig,
+	serverConfig *config.ServerConfig,
+	buildPath string,
+) error {
+	compilerLogger.Ctx(ctx).Info("[Worker %d] Compiling MD file: %s", worker, mdFile)
+	htmlFile, err := CompileHTMLFile(mdFile, siteConfig, serverConfig.ContentPath)
+	compilerLogger.Ctx(ctx).Debug("[Worker %d] Compiling HTML file: %s", worker, mdFile)
+	if err != nil {
+		compilerLogger.Ctx(ctx).Error("[Worker %d] Failed to compile HTML file: %v", worker, err)
+		return fmt.Errorf("failed to compile HTML file: %w", err)
+	}
 
-			return nil
-		})
+	// Calculate relative path from content directory to preserve folder structure
+	relPath, err := filepath.Rel(serverConfig.ContentPath, mdFile)
+	if err != nil {
+		compilerLogger.Ctx(ctx).Error("[Worker %d] Failed to get relative path for %s: %v", worker, mdFile, err)
+		return fmt.Errorf("failed to get relative path for %s: %w", mdFile, err)
 	}
 
-	if err := g.Wait(); err != nil {
-		return fmt.Errorf("failed to compile HTML files: %w", err)
+	metadata, err := GetFileMetadata(mdFile)
+	if err != nil {
+		compilerLogger.Ctx(ctx).Warn("[Worker %d] Failed to get metadata for %s: %v", worker, mdFile, err)
+	}
+	pagePath := GetPagePath(relPath, metadata, siteConfig.Site.URLs.Slugify)
+
+	// save the HTML file to the compiled HTML path
+	// write files to the build path /html/ by their site map path
+	compilerLogger.Ctx(ctx).Debug("[Worker %d] Writing HTML file: %s to %s", worker, pagePath, buildPath)
+
+	err = WriteHTMLFile(buildPath, pagePath+".html", htmlFile)
+	if err != nil {
+		compilerLogger.Ctx(ctx).Error("[Worker %d] Failed to write HTML file: %v", worker, err)
+		return fmt.Errorf("failed to write HTML file: %w", err)
 	}
 
-	logger.Info("Successfully compiled all MD files")
 	return nil
 }
 
-// CompileHTMLFile converts a markdown file to an HTML string
-func CompileHTMLFile(filePath string, siteConfig *config.SiteConfig) (string, error) {
+// CompileHTMLFile converts a markdown file of the content directory at contentPath to an HTML string
+func CompileHTMLFile(filePath string, siteConfig *config.SiteConfig, contentPath string) (string, error) {
 	// 1. Read the file from disk
 	content, err := os.ReadFile(filepath.Clean(filePath))
 	if err != nil {
@@ -145,6 +190,9 @@ func CompileHTMLFile(filePath string, siteConfig *config.SiteConfig) (string, er
 	htmlContent := buf.String()
 	htmlContent = replaceAssetPaths(htmlContent)
 
+	// 4. Replace links to markdown files with the URLs of their pages
+	htmlContent = replacePageLinks(htmlContent, filePath, contentPath, siteConfig.Site.URLs)
+
 	return htmlContent, nil
 }
 
@@ -245,7 +293,7 @@ func GetFirstParagraph(HTMLContent string) (string, error) {
 	})
 
 	if firstNonEmptyParagraph == "" {
-		logger.Debug("No non-empty paragraph found in HTML content")
+		compilerLogger.Debug("No non-empty paragraph found in HTML content")
 		return "", nil
 	}
 
@@ -266,3 +314,29 @@ func getFirstImage(HTMLContent string) (string, error) {
 
 	return src, nil
 }
+
+func getImages(HTMLContent string) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(HTMLContent))
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if exists && strings.TrimSpace(src) != "" && !slices.Contains(images, src) {
+			images = append(images, src)
+		}
+	})
+
+	return images, nil
+}
+
+// getText returns the plain text of an HTML document
+func getText(HTMLContent string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(HTMLContent))
+	if err != nil {
+		return "", err
+	}
+	return doc.Text(), nil
+}
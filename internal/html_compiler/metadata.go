@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -13,7 +15,32 @@ type Metadata struct {
 	CreationDate         time.Time `json:"creation_date"`
 	Description          string    `json:"description"`
 	Author               string    `json:"author"`
+	Authors              []string  `json:"authors,omitempty"`
 	LastModificationDate time.Time `json:"last_modification_date"`
+	// sitemap hints
+	ChangeFreq string   `json:"changefreq,omitempty"`
+	Priority   *float64 `json:"priority,omitempty"`
+	NoIndex    bool     `json:"noindex,omitempty"`
+	// multi-part series the page belongs to and its position in the series
+	Series      string `json:"series,omitempty"`
+	SeriesOrder int    `json:"series_order,omitempty"`
+	// navigation tree hints, lower weights are listed first
+	Weight   int    `json:"weight,omitempty"`
+	NavTitle string `json:"nav_title,omitempty"`
+	// old paths of the page that redirect to it (ex. /blog/old-title)
+	Aliases []string `json:"aliases,omitempty"`
+	// URL overrides, slug replaces the last path segment and url the whole path
+	Slug string `json:"slug,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// GetFileMetadata reads the metadata of a markdown file
+func GetFileMetadata(filePath string) (*Metadata, error) {
+	content, err := os.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return GetMetadata(string(content))
 }
 
 func GetMetadata(markdownContent string) (*Metadata, error) {
@@ -45,7 +72,18 @@ func GetMetadata(markdownContent string) (*Metadata, error) {
 		CreationDate:         metadata.CreationDate,
 		Description:          metadata.Description,
 		Author:               metadata.Author,
+		Authors:              metadata.Authors,
 		LastModificationDate: metadata.LastModificationDate,
+		ChangeFreq:           metadata.ChangeFreq,
+		Priority:             metadata.Priority,
+		NoIndex:              metadata.NoIndex,
+		Series:               metadata.Series,
+		SeriesOrder:          metadata.SeriesOrder,
+		Weight:               metadata.Weight,
+		NavTitle:             metadata.NavTitle,
+		Aliases:              metadata.Aliases,
+		Slug:                 metadata.Slug,
+		URL:                  metadata.URL,
 	}, nil
 }
 
@@ -66,3 +104,33 @@ func GetCreationDate(siteMapEntry SiteMapEntry) time.Time {
 	}
 	return siteMapEntry.CreationDate
 }
+
+// GetURLPath returns the canonical URL path a site map entry is served at.
+// The root index page is served at "/"
+func GetURLPath(siteMapEntry SiteMapEntry) string {
+	if siteMapEntry.URL != "" {
+		return siteMapEntry.URL
+	}
+	if siteMapEntry.Path == "index" || siteMapEntry.Path == "" {
+		return "/"
+	}
+	return "/" + strings.TrimPrefix(siteMapEntry.Path, "/")
+}
+
+// IsIndexable reports whether a page may be listed for search engines
+func IsIndexable(siteMapEntry SiteMapEntry) bool {
+	return siteMapEntry.Metadata == nil || !siteMapEntry.Metadata.NoIndex
+}
+
+// GetAuthorNames returns the author and authors metadata of a page
+func GetAuthorNames(siteMapEntry SiteMapEntry) []string {
+	if siteMapEntry.Metadata == nil {
+		return nil
+	}
+
+	var names []string
+	if siteMapEntry.Metadata.Author != "" {
+		names = append(names, siteMapEntry.Metadata.Author)
+	}
+	return append(names, siteMapEntry.Metadata.Authors...)
+}
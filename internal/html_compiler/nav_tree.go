@@ -0,0 +1,276 @@
+package htmlcompiler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+)
+
+// NavNode is a page or directory in the navigation tree built from the content directory
+type NavNode struct {
+	Title string `json:"title"`
+	// site map path of the page, empty for directories without an index page
+	Path     string    `json:"path"`
+	URL      string    `json:"url"`
+	Weight   int       `json:"weight"`
+	Children []NavNode `json:"children,omitempty"`
+	// Current is set on the page being viewed and Active on it and its
+	// ancestors, see MarkNavigation
+	Current bool `json:"-"`
+	Active  bool `json:"-"`
+}
+
+type navBuilder struct {
+	entry    *SiteMapEntry
+	children map[string]*navBuilder
+}
+
+// GenerateNavigation builds the navigation tree of the site map. Siblings are
+// ordered by the .order file of their directory, then by their weight
+// metadata, then by title. Names in .order files are slugified with policy
+// to match the site map paths
+func GenerateNavigation(
+	contentPath string,
+	siteMap []SiteMapEntry,
+	policy config.SlugifyPolicy,
+) ([]NavNode, error) {
+	orders, err := loadNavOrders(contentPath, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &navBuilder{children: map[string]*navBuilder{}}
+	for i := range siteMap {
+		node := root
+		for _, segment := range strings.Split(siteMap[i].Path, "/") {
+			child, ok := node.children[segment]
+			if !ok {
+				child = &navBuilder{children: map[string]*navBuilder{}}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.entry = &siteMap[i]
+	}
+
+	return buildNavNodes(root, "", orders), nil
+}
+
+func buildNavNodes(parent *navBuilder, dir string, orders map[string]map[string]int) []NavNode {
+	if len(parent.children) == 0 {
+		return nil
+	}
+
+	type namedNode struct {
+		name string
+		node NavNode
+	}
+
+	siblings := make([]namedNode, 0, len(parent.children))
+	for name, child := range parent.children {
+		node := NavNode{
+			Title:    PrettifySegment(name),
+			Children: buildNavNodes(child, joinNavPath(dir, name), orders),
+		}
+		if child.entry != nil {
+			node.Title = GetNavTitle(*child.entry)
+			node.Path = child.entry.Path
+			node.URL = GetURLPath(*child.entry)
+			if child.entry.Metadata != nil {
+				node.Weight = child.entry.Metadata.Weight
+			}
+		}
+		siblings = append(siblings, namedNode{name: name, node: node})
+	}
+
+	order := orders[dir]
+	sort.Slice(siblings, func(i, j int) bool {
+		iOrder, iListed := order[siblings[i].name]
+		jOrder, jListed := order[siblings[j].name]
+		if iListed != jListed {
+			return iListed
+		}
+		if iListed && iOrder != jOrder {
+			return iOrder < jOrder
+		}
+		if siblings[i].node.Weight != siblings[j].node.Weight {
+			return siblings[i].node.Weight < siblings[j].node.Weight
+		}
+		iTitle, jTitle := strings.ToLower(siblings[i].node.Title), strings.ToLower(siblings[j].node.Title)
+		if iTitle != jTitle {
+			return iTitle < jTitle
+		}
+		return siblings[i].name < siblings[j].name
+	})
+
+	nodes := make([]NavNode, len(siblings))
+	for i, sibling := range siblings {
+		nodes[i] = sibling.node
+	}
+	return nodes
+}
+
+// loadNavOrders reads the .order files of the content directory. Each line
+// names a page or directory, with or without the .md extension. Lines
+// starting with # are comments. The result maps the site map path of a
+// directory to the position of each listed name
+func loadNavOrders(contentPath string, policy config.SlugifyPolicy) (map[string]map[string]int, error) {
+	orders := map[string]map[string]int{}
+
+	err := filepath.WalkDir(contentPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != constants.NavOrderFileName {
+			return nil
+		}
+
+		dir, err := filepath.Rel(contentPath, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		dir = filepath.ToSlash(dir)
+		if dir == "." {
+			dir = ""
+		} else {
+			dir = formatNavPath(dir, policy)
+		}
+
+		order, err := readNavOrder(path, policy)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		orders[dir] = order
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load navigation order files: %w", err)
+	}
+
+	return orders, nil
+}
+
+func readNavOrder(path string, policy config.SlugifyPolicy) (map[string]int, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	order := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := formatNavPath(strings.TrimSuffix(line, "/"), policy)
+		if _, ok := order[name]; !ok {
+			order[name] = len(order)
+		}
+	}
+
+	return order, scanner.Err()
+}
+
+// formatNavPath formats a relative file or directory path the way page paths
+// are formatted in the site map
+func formatNavPath(name string, policy config.SlugifyPolicy) string {
+	segments := strings.Split(strings.TrimSuffix(name, ".md"), "/")
+	for i, segment := range segments {
+		segments[i] = Slugify(segment, policy)
+	}
+	return strings.Join(segments, "/")
+}
+
+func joinNavPath(dir string, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// MarkNavigation returns a copy of the navigation tree with the page at
+// currentPath marked as current and it and its ancestors marked as active
+func MarkNavigation(nodes []NavNode, currentPath string) []NavNode {
+	if nodes == nil {
+		return nil
+	}
+
+	marked := make([]NavNode, len(nodes))
+	for i, node := range nodes {
+		node.Children = MarkNavigation(node.Children, currentPath)
+		node.Current = node.Path != "" && node.Path == currentPath
+		node.Active = node.Current
+		for _, child := range node.Children {
+			if child.Active {
+				node.Active = true
+				break
+			}
+		}
+		marked[i] = node
+	}
+
+	return marked
+}
+
+// GetNavTitle returns the title of a page in navigation, the nav_title
+// metadata, the first header or the prettified last path segment
+func GetNavTitle(siteMapEntry SiteMapEntry) string {
+	if siteMapEntry.Metadata != nil && siteMapEntry.Metadata.NavTitle != "" {
+		return siteMapEntry.Metadata.NavTitle
+	}
+	if siteMapEntry.FirstHeader != "" {
+		return siteMapEntry.FirstHeader
+	}
+	segments := strings.Split(siteMapEntry.Path, "/")
+	return PrettifySegment(segments[len(segments)-1])
+}
+
+// PrettifySegment turns a path segment into a title (ex. getting_started -> Getting started)
+func PrettifySegment(segment string) string {
+	title := strings.TrimSpace(strings.NewReplacer("_", " ", "-", " ").Replace(segment))
+	first, size := utf8.DecodeRuneInString(title)
+	if first == utf8.RuneError {
+		return title
+	}
+	return string(unicode.ToUpper(first)) + title[size:]
+}
+
+func SaveNavigation(navigation []NavNode, filePath string) error {
+	jsonContent, err := json.Marshal(navigation)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filePath, jsonContent, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save navigation to %s: %w", filePath, err)
+	}
+	return nil
+}
+
+func LoadNavigation(filePath string) ([]NavNode, error) {
+	jsonContent, err := os.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	var navigation []NavNode
+	err = json.Unmarshal(jsonContent, &navigation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal navigation from %s: %w", filePath, err)
+	}
+
+	return navigation, nil
+}
@@ -0,0 +1,92 @@
+package htmlcompiler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+func TestGenerateNavigation(t *testing.T) {
+	contentPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(contentPath, "docs"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	order := "# getting started first\ngetting started.md\n\nreference/\n"
+	if err := os.WriteFile(filepath.Join(contentPath, "docs", ".order"), []byte(order), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	siteMap := []SiteMapEntry{
+		{Path: "index", FirstHeader: "Home", Metadata: &Metadata{Weight: -1}},
+		{Path: "docs", FirstHeader: "Documentation"},
+		{Path: "docs/reference/api", FirstHeader: "API"},
+		{Path: "docs/advanced", FirstHeader: "Advanced", Metadata: &Metadata{Weight: 2}},
+		{Path: "docs/faq", FirstHeader: "FAQ", Metadata: &Metadata{Weight: 1}},
+		{Path: "docs/getting_started", FirstHeader: "Getting Started", Metadata: &Metadata{NavTitle: "Start Here"}},
+		{Path: "about", FirstHeader: "About"},
+	}
+
+	navigation, err := GenerateNavigation(contentPath, siteMap, config.SlugifyPreserve)
+	if err != nil {
+		t.Fatalf("GenerateNavigation returned error: %v", err)
+	}
+
+	if got := navTitles(navigation); got != "Home,About,Documentation" {
+		t.Errorf("top level = %s, want Home,About,Documentation", got)
+	}
+
+	docs := navigation[2]
+	if docs.URL != "/docs" || docs.Path != "docs" {
+		t.Errorf("docs node = %+v, want the docs index page", docs)
+	}
+	if got := navTitles(docs.Children); got != "Start Here,Reference,FAQ,Advanced" {
+		t.Errorf("docs children = %s, want Start Here,Reference,FAQ,Advanced", got)
+	}
+
+	reference := docs.Children[1]
+	if reference.URL != "" || len(reference.Children) != 1 || reference.Children[0].URL != "/docs/reference/api" {
+		t.Errorf("reference node = %+v, want a directory holding the api page", reference)
+	}
+
+	marked := MarkNavigation(navigation, "docs/reference/api")
+	if !marked[2].Active || marked[2].Current {
+		t.Errorf("docs should be an active ancestor, got %+v", marked[2])
+	}
+	if !marked[2].Children[1].Active {
+		t.Errorf("reference should be an active ancestor")
+	}
+	if api := marked[2].Children[1].Children[0]; !api.Current || !api.Active {
+		t.Errorf("api should be the current page, got %+v", api)
+	}
+	if marked[0].Active || marked[1].Active {
+		t.Errorf("unrelated pages should not be active")
+	}
+	if navigation[2].Active {
+		t.Errorf("MarkNavigation should not modify the original tree")
+	}
+}
+
+func TestPrettifySegment(t *testing.T) {
+	tests := map[string]string{
+		"getting_started": "Getting started",
+		"api-reference":   "Api reference",
+		"":                "",
+		"élan":            "Élan",
+	}
+	for input, want := range tests {
+		if got := PrettifySegment(input); got != want {
+			t.Errorf("PrettifySegment(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func navTitles(nodes []NavNode) string {
+	titles := make([]string, len(nodes))
+	for i, node := range nodes {
+		titles[i] = node.Title
+	}
+	return strings.Join(titles, ",")
+}
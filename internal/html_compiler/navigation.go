@@ -0,0 +1,60 @@
+package htmlcompiler
+
+import (
+	"sort"
+)
+
+// GetSeries returns the pages of a series ordered by their series_order
+// metadata. Pages with the same order keep their site map order
+func GetSeries(siteMap []SiteMapEntry, series string) []SiteMapEntry {
+	if series == "" {
+		return nil
+	}
+
+	var pages []SiteMapEntry
+	for _, page := range siteMap {
+		if page.Metadata != nil && page.Metadata.Series == series {
+			pages = append(pages, page)
+		}
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Metadata.SeriesOrder < pages[j].Metadata.SeriesOrder
+	})
+
+	return pages
+}
+
+// GetPrevNext returns the pages before and after an entry. Pages that belong
+// to a series navigate within the series, other pages navigate within their
+// section in site map order (see SortSiteMap)
+func GetPrevNext(siteMap []SiteMapEntry, entry SiteMapEntry) (*SiteMapEntry, *SiteMapEntry) {
+	var pages []SiteMapEntry
+	if entry.Metadata != nil && entry.Metadata.Series != "" {
+		pages = GetSeries(siteMap, entry.Metadata.Series)
+	} else {
+		section := getSection(entry.Path)
+		for _, page := range siteMap {
+			if getSection(page.Path) == section {
+				pages = append(pages, page)
+			}
+		}
+	}
+
+	for i, page := range pages {
+		if page.Path != entry.Path {
+			continue
+		}
+
+		var prev, next *SiteMapEntry
+		if i > 0 {
+			prev = &pages[i-1]
+		}
+		if i < len(pages)-1 {
+			next = &pages[i+1]
+		}
+		return prev, next
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,70 @@
+package htmlcompiler
+
+import (
+	"testing"
+)
+
+func TestGetPrevNext(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "blog/newest"},
+		{Path: "blog/part-2", Metadata: &Metadata{Series: "go", SeriesOrder: 2}},
+		{Path: "docs/intro"},
+		{Path: "blog/part-1", Metadata: &Metadata{Series: "go", SeriesOrder: 1}},
+		{Path: "blog/oldest"},
+		{Path: "blog/part-3", Metadata: &Metadata{Series: "go", SeriesOrder: 3}},
+	}
+
+	tests := []struct {
+		name         string
+		entry        SiteMapEntry
+		expectedPrev string
+		expectedNext string
+	}{
+		{
+			name:         "First page of a section",
+			entry:        siteMap[0],
+			expectedPrev: "",
+			expectedNext: "blog/part-2",
+		},
+		{
+			name:         "Series page navigates within the series",
+			entry:        siteMap[1],
+			expectedPrev: "blog/part-1",
+			expectedNext: "blog/part-3",
+		},
+		{
+			name:         "Only page in its section",
+			entry:        siteMap[2],
+			expectedPrev: "",
+			expectedNext: "",
+		},
+		{
+			name:         "Section page skips other sections",
+			entry:        siteMap[4],
+			expectedPrev: "blog/part-1",
+			expectedNext: "blog/part-3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, next := GetPrevNext(siteMap, tt.entry)
+
+			prevPath := ""
+			if prev != nil {
+				prevPath = prev.Path
+			}
+			nextPath := ""
+			if next != nil {
+				nextPath = next.Path
+			}
+
+			if prevPath != tt.expectedPrev {
+				t.Errorf("Expected prev %q, got %q", tt.expectedPrev, prevPath)
+			}
+			if nextPath != tt.expectedNext {
+				t.Errorf("Expected next %q, got %q", tt.expectedNext, nextPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package htmlcompiler
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/constants"
+)
+
+// Redirect is a redirect from a request path resolved from page aliases or the site config
+type Redirect struct {
+	From   string
+	To     string
+	Status int
+}
+
+var redirectStubTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8" />
+    <title>Redirecting…</title>
+    <meta name="robots" content="noindex" />
+    <meta http-equiv="refresh" content="0; url={{ .To }}" />
+    <link rel="canonical" href="{{ .To }}" />
+  </head>
+  <body>
+    <p>This page has moved to <a href="{{ .To }}">{{ .To }}</a>.</p>
+  </body>
+</html>
+`))
+
+// NormalizeRedirectPath cleans a path so aliases and request paths compare
+// equal with or without a trailing slash
+func NormalizeRedirectPath(requestPath string) string {
+	return path.Clean("/" + strings.TrimSpace(requestPath))
+}
+
+// GetAliasRedirects returns a permanent redirect to every page from each of its aliases
+func GetAliasRedirects(siteMap []SiteMapEntry) []Redirect {
+	var redirects []Redirect
+	for _, page := range siteMap {
+		if page.Metadata == nil {
+			continue
+		}
+		for _, alias := range page.Metadata.Aliases {
+			if strings.TrimSpace(alias) == "" {
+				continue
+			}
+			redirects = append(redirects, Redirect{
+				From:   NormalizeRedirectPath(alias),
+				To:     GetURLPath(page),
+				Status: http.StatusMovedPermanently,
+			})
+		}
+	}
+	return redirects
+}
+
+// Redirects resolves the redirects of a build: the aliases of its pages,
+// indexed once, then the redirects of the site config
+type Redirects struct {
+	aliases   map[string]Redirect
+	redirects []config.Redirect
+}
+
+// NewRedirects returns the Redirects of a site map and the redirects of the
+// site config, whose regex patterns were compiled by its validation
+func NewRedirects(siteMap []SiteMapEntry, redirects []config.Redirect) *Redirects {
+	aliases := map[string]Redirect{}
+	for _, redirect := range GetAliasRedirects(siteMap) {
+		// the first page claiming an alias wins
+		if _, ok := aliases[redirect.From]; !ok {
+			aliases[redirect.From] = redirect
+		}
+	}
+	return &Redirects{aliases: aliases, redirects: redirects}
+}
+
+// Resolve returns the redirect for a request path. Page aliases are checked
+// first, then the redirects of the site config in order. A nil Redirects
+// resolves nothing
+func (r *Redirects) Resolve(requestPath string) (Redirect, bool) {
+	if r == nil {
+		return Redirect{}, false
+	}
+	requestPath = NormalizeRedirectPath(requestPath)
+
+	if redirect, ok := r.aliases[requestPath]; ok {
+		return redirect, true
+	}
+
+	for _, redirect := range r.redirects {
+		if !redirect.Regex {
+			if NormalizeRedirectPath(redirect.From) == requestPath {
+				return Redirect{From: requestPath, To: redirect.To, Status: redirect.Status}, true
+			}
+			continue
+		}
+
+		if redirect.Pattern == nil {
+			compilerLogger.Error("Redirect regex %s was not compiled", redirect.From)
+			continue
+		}
+		match := redirect.Pattern.FindStringSubmatchIndex(requestPath)
+		if match == nil {
+			continue
+		}
+		target := string(redirect.Pattern.ExpandString(nil, redirect.To, requestPath, match))
+		return Redirect{From: requestPath, To: target, Status: redirect.Status}, true
+	}
+
+	return Redirect{}, false
+}
+
+// GetStaticRedirects returns the redirects that can be exported as meta
+// refresh pages: page aliases and exact 301/302 redirects of the site config.
+// Regex redirects and 410s need the server to answer them. Like
+// Redirects.Resolve, the first redirect from a path wins
+func GetStaticRedirects(siteMap []SiteMapEntry, redirects []config.Redirect) []Redirect {
+	candidates := GetAliasRedirects(siteMap)
+	for _, redirect := range redirects {
+		if redirect.Regex || redirect.Status == http.StatusGone {
+			continue
+		}
+		candidates = append(candidates, Redirect{
+			From:   NormalizeRedirectPath(redirect.From),
+			To:     redirect.To,
+			Status: redirect.Status,
+		})
+	}
+
+	var static []Redirect
+	seen := map[string]bool{}
+	for _, redirect := range candidates {
+		if seen[redirect.From] {
+			continue
+		}
+		seen[redirect.From] = true
+		static = append(static, redirect)
+	}
+	return static
+}
+
+// WriteRedirectStubs writes a meta refresh page for each redirect to the HTML
+// files of basePath, where the pages are: html/<from>.html, or
+// html/<from>/index.html when the page URLs end with a slash. A redirect from
+// the path of a page is skipped, the page is served there
+func WriteRedirectStubs(
+	ctx context.Context,
+	basePath string,
+	redirects []Redirect,
+	policy config.TrailingSlashPolicy,
+) error {
+	htmlPath := filepath.Join(basePath, constants.HTMLFilesPath)
+	for _, redirect := range redirects {
+		if redirect.From == "/" {
+			compilerLogger.Ctx(ctx).Warn("Skipping redirect stub for the root path to %s", redirect.To)
+			continue
+		}
+
+		pagePath := redirectStubPath(htmlPath, redirect.From, config.TrailingSlashIgnore)
+		if _, err := os.Stat(pagePath); err == nil {
+			compilerLogger.Ctx(ctx).Warn("Skipping redirect stub for %s, a page is served there", redirect.From)
+			continue
+		}
+
+		stubPath := redirectStubPath(htmlPath, redirect.From, policy)
+		if err := os.MkdirAll(filepath.Dir(stubPath), 0750); err != nil {
+			return fmt.Errorf("failed to create redirect directory %s: %w", filepath.Dir(stubPath), err)
+		}
+
+		var stub strings.Builder
+		if err := redirectStubTemplate.Execute(&stub, redirect); err != nil {
+			return fmt.Errorf("failed to render redirect stub for %s: %w", redirect.From, err)
+		}
+
+		if err := os.WriteFile(stubPath, []byte(stub.String()), 0600); err != nil {
+			return fmt.Errorf("failed to write redirect stub %s: %w", stubPath, err)
+		}
+	}
+
+	return nil
+}
+
+// redirectStubPath returns the path of the stub of a redirect from a request
+// path under htmlPath, laid out like the HTML files of the pages
+func redirectStubPath(htmlPath string, from string, policy config.TrailingSlashPolicy) string {
+	stubPath := filepath.Join(htmlPath, filepath.FromSlash(strings.TrimPrefix(from, "/")))
+	if policy == config.TrailingSlashAlways {
+		return filepath.Join(stubPath, "index.html")
+	}
+	return stubPath + ".html"
+}
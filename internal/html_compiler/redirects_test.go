@@ -0,0 +1,109 @@
+package htmlcompiler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+func TestRedirectsResolve(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "blog/posts/new", Metadata: &Metadata{Aliases: []string{"/blog/old/", "posts/older"}}},
+		{Path: "about"},
+	}
+	redirects := []config.Redirect{
+		{From: "/blog/old", To: "/not-used", Status: http.StatusFound},
+		{From: "/team/", To: "/about", Status: http.StatusMovedPermanently},
+		{From: "^/archive/(\\d+)/(.*)$", To: "/blog/posts/$2?year=$1", Regex: true, Status: http.StatusFound,
+			Pattern: regexp.MustCompile("^/archive/(\\d+)/(.*)$")},
+		{From: "/drafts/removed", Status: http.StatusGone},
+	}
+
+	tests := []struct {
+		path     string
+		found    bool
+		expected Redirect
+	}{
+		{"/blog/old", true, Redirect{From: "/blog/old", To: "/blog/posts/new", Status: http.StatusMovedPermanently}},
+		{"/posts/older/", true, Redirect{From: "/posts/older", To: "/blog/posts/new", Status: http.StatusMovedPermanently}},
+		{"/team", true, Redirect{From: "/team", To: "/about", Status: http.StatusMovedPermanently}},
+		{"/archive/2024/hello", true, Redirect{From: "/archive/2024/hello", To: "/blog/posts/hello?year=2024", Status: http.StatusFound}},
+		{"/drafts/removed", true, Redirect{From: "/drafts/removed", Status: http.StatusGone}},
+		{"/missing", false, Redirect{}},
+	}
+
+	resolver := NewRedirects(siteMap, redirects)
+	for _, tt := range tests {
+		got, found := resolver.Resolve(tt.path)
+		if found != tt.found || got != tt.expected {
+			t.Errorf("Resolve(%s) = %+v, %v, want %+v, %v", tt.path, got, found, tt.expected, tt.found)
+		}
+	}
+
+	static := GetStaticRedirects(siteMap, redirects)
+	var froms []string
+	for _, redirect := range static {
+		froms = append(froms, redirect.From)
+	}
+	if got := strings.Join(froms, ","); got != "/blog/old,/posts/older,/team" {
+		t.Errorf("GetStaticRedirects() froms = %s", got)
+	}
+
+}
+
+func TestWriteRedirectStubs(t *testing.T) {
+	redirects := []Redirect{
+		{From: "/blog/old", To: "/blog/posts/new", Status: http.StatusMovedPermanently},
+		{From: "/about", To: "/team", Status: http.StatusMovedPermanently},
+	}
+
+	tests := []struct {
+		policy   config.TrailingSlashPolicy
+		stubPath string
+	}{
+		{config.TrailingSlashIgnore, filepath.Join("html", "blog", "old.html")},
+		{config.TrailingSlashNever, filepath.Join("html", "blog", "old.html")},
+		{config.TrailingSlashAlways, filepath.Join("html", "blog", "old", "index.html")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			basePath := t.TempDir()
+			pagePath := filepath.Join(basePath, "html", "about.html")
+			if err := os.MkdirAll(filepath.Dir(pagePath), 0750); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(pagePath, []byte("<p>About</p>"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := WriteRedirectStubs(context.Background(), basePath, redirects, tt.policy); err != nil {
+				t.Fatalf("WriteRedirectStubs returned error: %v", err)
+			}
+			stub, err := os.ReadFile(filepath.Join(basePath, tt.stubPath))
+			if err != nil {
+				t.Fatalf("failed to read redirect stub: %v", err)
+			}
+			if !strings.Contains(string(stub), `content="0; url=/blog/posts/new"`) {
+				t.Errorf("redirect stub is missing the meta refresh:\n%s", stub)
+			}
+
+			if _, err := os.Stat(filepath.Join(basePath, "html", "about", "index.html")); !os.IsNotExist(err) {
+				t.Errorf("redirect stub written at the path of a page")
+			}
+			page, err := os.ReadFile(pagePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(page) != "<p>About</p>" {
+				t.Errorf("redirect stub overwrote the page at its path:\n%s", page)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package htmlcompiler
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// weights of the tag overlap and text similarity in the related score
+	relatedTagWeight  = 0.4
+	relatedTextWeight = 0.6
+	// tokens shorter than this are ignored when comparing page bodies
+	minRelatedTokenLength = 3
+)
+
+var relatedStopWords = map[string]struct{}{
+	"the": {}, "and": {}, "for": {}, "are": {}, "but": {}, "not": {}, "you": {},
+	"all": {}, "any": {}, "can": {}, "her": {}, "was": {}, "one": {}, "our": {},
+	"out": {}, "has": {}, "have": {}, "had": {}, "this": {}, "that": {}, "with": {},
+	"from": {}, "they": {}, "will": {}, "would": {}, "there": {}, "their": {},
+	"what": {}, "about": {}, "which": {}, "when": {}, "make": {}, "like": {},
+	"into": {}, "than": {}, "them": {}, "then": {}, "these": {}, "some": {},
+	"its": {}, "also": {}, "more": {}, "such": {}, "only": {}, "your": {},
+	"how": {}, "use": {}, "using": {}, "each": {}, "other": {}, "were": {},
+	"been": {}, "being": {}, "does": {}, "did": {}, "just": {}, "over": {},
+	"very": {}, "here": {}, "where": {}, "who": {}, "why": {}, "may": {},
+}
+
+// ComputeRelatedPages fills the Related field of every entry with the paths of
+// up to count pages from the same section, ranked by tag overlap and TF-IDF
+// similarity of the page bodies. bodies holds the plain text of each entry
+func ComputeRelatedPages(siteMap []SiteMapEntry, bodies []string, count int) {
+	if count <= 0 || len(siteMap) == 0 {
+		return
+	}
+
+	vectors := buildTFIDFVectors(bodies)
+
+	for i := range siteMap {
+		type candidate struct {
+			path  string
+			score float64
+		}
+		var candidates []candidate
+
+		section := getSection(siteMap[i].Path)
+		for j := range siteMap {
+			if i == j || getSection(siteMap[j].Path) != section {
+				continue
+			}
+
+			score := relatedTagWeight*tagOverlap(siteMap[i].Metadata, siteMap[j].Metadata) +
+				relatedTextWeight*cosineSimilarity(vectors[i], vectors[j])
+			if score > 0 {
+				candidates = append(candidates, candidate{path: siteMap[j].Path, score: score})
+			}
+		}
+
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].score == candidates[b].score {
+				return candidates[a].path < candidates[b].path
+			}
+			return candidates[a].score > candidates[b].score
+		})
+
+		related := make([]string, 0, min(count, len(candidates)))
+		for _, c := range candidates[:min(count, len(candidates))] {
+			related = append(related, c.path)
+		}
+		siteMap[i].Related = related
+	}
+}
+
+// getSection returns the directory a page lives in, pages are only related to
+// pages of the same section
+func getSection(pagePath string) string {
+	return path.Dir(pagePath)
+}
+
+func tagOverlap(a *Metadata, b *Metadata) float64 {
+	if a == nil || b == nil || len(a.Tags) == 0 || len(b.Tags) == 0 {
+		return 0
+	}
+
+	tags := map[string]bool{}
+	for _, tag := range a.Tags {
+		tags[strings.ToLower(tag)] = true
+	}
+
+	union := len(tags)
+	shared := 0
+	seen := map[string]bool{}
+	for _, tag := range b.Tags {
+		tag = strings.ToLower(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		if tags[tag] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	// jaccard index
+	return float64(shared) / float64(union)
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) < minRelatedTokenLength {
+			continue
+		}
+		if _, stop := relatedStopWords[word]; stop {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+func buildTFIDFVectors(bodies []string) []map[string]float64 {
+	termFrequencies := make([]map[string]float64, len(bodies))
+	documentFrequency := map[string]int{}
+
+	for i, body := range bodies {
+		tokens := tokenize(body)
+		tf := map[string]float64{}
+		for _, token := range tokens {
+			tf[token]++
+		}
+		for token := range tf {
+			tf[token] /= float64(len(tokens))
+			documentFrequency[token]++
+		}
+		termFrequencies[i] = tf
+	}
+
+	documents := float64(len(bodies))
+	vectors := make([]map[string]float64, len(bodies))
+	for i, tf := range termFrequencies {
+		vector := make(map[string]float64, len(tf))
+		for token, frequency := range tf {
+			// smoothed idf so terms found in every document still count a little
+			idf := math.Log((1+documents)/(1+float64(documentFrequency[token]))) + 1
+			vector[token] = frequency * idf
+		}
+		vectors[i] = vector
+	}
+
+	return vectors
+}
+
+func cosineSimilarity(a map[string]float64, b map[string]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for token, weight := range a {
+		normA += weight * weight
+		if other, ok := b[token]; ok {
+			dot += weight * other
+		}
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,71 @@
+package htmlcompiler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComputeRelatedPages(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "blog/go-channels", Metadata: &Metadata{Tags: []string{"go", "concurrency"}}},
+		{Path: "blog/go-goroutines", Metadata: &Metadata{Tags: []string{"go", "concurrency"}}},
+		{Path: "blog/baking-bread", Metadata: &Metadata{Tags: []string{"cooking"}}},
+		{Path: "docs/go-channels", Metadata: &Metadata{Tags: []string{"go", "concurrency"}}},
+	}
+	bodies := []string{
+		"Channels let goroutines communicate. Buffered channels and unbuffered channels.",
+		"Goroutines are lightweight threads. Goroutines communicate over channels.",
+		"Bread needs flour, water, salt and yeast. Knead the dough.",
+		"Channels let goroutines communicate. Buffered channels and unbuffered channels.",
+	}
+
+	ComputeRelatedPages(siteMap, bodies, 2)
+
+	tests := []struct {
+		name     string
+		entry    SiteMapEntry
+		expected []string
+	}{
+		{
+			name:     "Most similar page first",
+			entry:    siteMap[0],
+			expected: []string{"blog/go-goroutines"},
+		},
+		{
+			name:     "Unrelated page has no related pages",
+			entry:    siteMap[2],
+			expected: []string{},
+		},
+		{
+			name:     "Pages in other sections are never related",
+			entry:    siteMap[3],
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.entry.Related, tt.expected) {
+				t.Errorf("Expected related pages %v, got %v", tt.expected, tt.entry.Related)
+			}
+		})
+	}
+}
+
+func TestComputeRelatedPagesRespectsCount(t *testing.T) {
+	siteMap := []SiteMapEntry{
+		{Path: "blog/a", Metadata: &Metadata{Tags: []string{"go"}}},
+		{Path: "blog/b", Metadata: &Metadata{Tags: []string{"go"}}},
+		{Path: "blog/c", Metadata: &Metadata{Tags: []string{"go"}}},
+		{Path: "blog/d", Metadata: &Metadata{Tags: []string{"go"}}},
+	}
+	bodies := make([]string, len(siteMap))
+
+	ComputeRelatedPages(siteMap, bodies, 2)
+
+	for _, entry := range siteMap {
+		if len(entry.Related) != 2 {
+			t.Errorf("Expected 2 related pages for %s, got %v", entry.Path, entry.Related)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package htmlcompiler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,20 +9,22 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/jaysongiroux/mdserve/internal/config"
 	"github.com/jaysongiroux/mdserve/internal/files"
-	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/git"
 )
 
+// ErrPageNotFound is returned when a path has no page in the site map
+var ErrPageNotFound = errors.New("page not found in site map")
+
 func SortSiteMap(
 	siteMap []SiteMapEntry,
 	sortDirection config.SortDirection,
 ) (*[]SiteMapEntry, error) {
 	if len(siteMap) == 0 {
-		logger.Debug("Site map is empty, returning empty site map")
+		compilerLogger.Debug("Site map is empty, returning empty site map")
 		return &siteMap, nil
 	}
 
@@ -63,9 +66,14 @@ func SortSiteMap(
 	return &siteMap, nil
 }
 
+// GenerateSiteMap builds the site map of every markdown file under markdownFilePath.
+// Page dates come from the metadata, then from history when the file has git
+// history (history may be nil), then from the file modification time
 func GenerateSiteMap(
+	ctx context.Context,
 	markdownFilePath string,
 	siteConfig *config.SiteConfig,
+	history map[string]git.FileHistory,
 ) (*[]SiteMapEntry, error) {
 	// crawl the markdown file path to get all mark down files
 	mdFiles, err := GetMDFiles(markdownFilePath)
@@ -74,10 +82,14 @@ func GenerateSiteMap(
 	}
 
 	var siteMap []SiteMapEntry
+	// source file of each page path, to report files with the same URL
+	pageSources := map[string]string{}
+	// plain text of each page, used to compute related pages
+	var bodies []string
 
 	for _, file := range mdFiles {
 		// convert the markdown file to an HTML string
-		htmlContent, err := CompileHTMLFile(file, siteConfig)
+		htmlContent, err := CompileHTMLFile(file, siteConfig, markdownFilePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to compile HTML file %s: %w", file, err)
 		}
@@ -97,74 +109,88 @@ func GenerateSiteMap(
 			return nil, fmt.Errorf("failed to get first image for file %s: %w", file, err)
 		}
 
-		markdownContent, err := os.ReadFile(filepath.Clean(file))
+		images, err := getImages(htmlContent)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read markdown content for file %s: %w", file, err)
+			return nil, fmt.Errorf("failed to get images for file %s: %w", file, err)
 		}
-		metadata, err := GetMetadata(string(markdownContent))
+
+		body, err := getText(htmlContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get text for file %s: %w", file, err)
+		}
+		bodies = append(bodies, body)
+
+		metadata, err := GetFileMetadata(file)
 		if err != nil {
-			logger.Warn("failed to get metadata for file %s: %v", file, err)
+			compilerLogger.Ctx(ctx).Warn("failed to get metadata for file %s: %v", file, err)
 			metadata = nil
 		}
 
-		var lastModifiedDate time.Time
 		fileModifiedDate, err := files.GetFileModifiedDate(file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get modified date for file %s: %w", file, err)
 		}
-		// handle if the modification date is not set in the metadata
-		if metadata != nil {
-			// check if LastModificationDate is set and not zero
-			if metadata.LastModificationDate.IsZero() {
-				lastModifiedDate = fileModifiedDate
-			} else {
-				lastModifiedDate = metadata.LastModificationDate
-			}
-		} else {
-			lastModifiedDate = fileModifiedDate
+		lastModifiedDate := fileModifiedDate
+		creationDate := fileModifiedDate
+
+		// prefer the commit dates over the file modification time
+		fileHistory, hasHistory := history[file]
+		if hasHistory && !fileHistory.Modified.IsZero() {
+			lastModifiedDate = fileHistory.Modified
+			creationDate = fileHistory.Created
 		}
 
-		var creationDate time.Time
+		// dates set in the metadata take precedence
 		if metadata != nil {
-			if metadata.CreationDate != (time.Time{}) {
+			if !metadata.LastModificationDate.IsZero() {
+				lastModifiedDate = metadata.LastModificationDate
+			}
+			if !metadata.CreationDate.IsZero() {
 				creationDate = metadata.CreationDate
-			} else {
-				creationDate = fileModifiedDate
 			}
-		} else {
-			creationDate = fileModifiedDate
 		}
 
-		// remove md extention
-		formattedPath := strings.TrimSuffix(file, ".md")
-		// remove the content/ prefix
-		formattedPath = strings.TrimPrefix(formattedPath, "content/")
-		// replace spaces with _
-		formattedPath = strings.ReplaceAll(formattedPath, " ", "_")
-
-		// if path ends in /index remove the /index
-		formattedPath = strings.TrimSuffix(formattedPath, "/index")
+		relPath, err := filepath.Rel(markdownFilePath, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relative path for file %s: %w", file, err)
+		}
+		formattedPath := GetPagePath(relPath, metadata, siteConfig.Site.URLs.Slugify)
+		if source, ok := pageSources[formattedPath]; ok {
+			// the HTML files of the pages would overwrite each other
+			return nil, fmt.Errorf("pages %s and %s have the same path %s", source, file, formattedPath)
+		}
+		pageSources[formattedPath] = file
 
 		siteMap = append(siteMap, SiteMapEntry{
 			Path:             formattedPath,
+			SourcePath:       filepath.ToSlash(relPath),
+			URL:              FormatURL(formattedPath, siteConfig.Site.URLs.TrailingSlash),
 			FirstHeader:      firstHeader,
 			FirstParagraph:   firstParagraph,
 			LastModifiedDate: lastModifiedDate,
 			Metadata:         metadata,
 			CreationDate:     creationDate,
 			FirstImage:       firstImage,
+			Images:           images,
+			CreatedBy:        fileHistory.CreatedBy,
+			ModifiedBy:       fileHistory.ModifiedBy,
 		})
 	}
 
+	if siteConfig.Site.Related.Count > 0 {
+		compilerLogger.Ctx(ctx).Debug("Computing up to %d related pages per page", siteConfig.Site.Related.Count)
+		ComputeRelatedPages(siteMap, bodies, siteConfig.Site.Related.Count)
+	}
+
 	if siteMap != nil {
-		logger.Debug("Sorting site map with sort direction: %s", siteConfig.Site.SortDirection)
+		compilerLogger.Ctx(ctx).Debug("Sorting site map with sort direction: %s", siteConfig.Site.SortDirection)
 		sortedSiteMap, err := SortSiteMap(siteMap, siteConfig.Site.SortDirection)
 		if err != nil {
-			logger.Error("Failed to sort site map: %v", err)
+			compilerLogger.Ctx(ctx).Error("Failed to sort site map: %v", err)
 			return nil, fmt.Errorf("failed to sort site map: %w", err)
 		}
 
-		logger.Debug("Successfully sorted site map")
+		compilerLogger.Ctx(ctx).Debug("Successfully sorted site map")
 
 		return sortedSiteMap, nil
 	}
@@ -199,6 +225,15 @@ func LoadSiteMap(filePath string) (*[]SiteMapEntry, error) {
 	return &siteMap, nil
 }
 
+// IndexSiteMap returns the pages of a site map by path
+func IndexSiteMap(siteMap []SiteMapEntry) map[string]SiteMapEntry {
+	pages := make(map[string]SiteMapEntry, len(siteMap))
+	for _, page := range siteMap {
+		pages[page.Path] = page
+	}
+	return pages
+}
+
 func FilterSiteMap(siteMap *[]SiteMapEntry, regex string) (*[]SiteMapEntry, error) {
 	regexObj, err := regexp.Compile(regex)
 	if err != nil {
@@ -218,21 +253,21 @@ func FilterSiteMap(siteMap *[]SiteMapEntry, regex string) (*[]SiteMapEntry, erro
 // path: the path of the page to get the sitemap entity for
 // siteMapPath: the path to the site map file
 func GetSitemapEntityByPath(path string, siteMapPath string) (*SiteMapEntry, error) {
-	logger.Debug("Getting sitemap entity by path: %s", path)
+	compilerLogger.Debug("Getting sitemap entity by path: %s", path)
 
 	siteMap, err := LoadSiteMap(siteMapPath)
 	if err != nil {
-		logger.Error("Failed to load sitemap: %v", err)
+		compilerLogger.Error("Failed to load sitemap: %v", err)
 		return nil, err
 	}
 
 	for _, page := range *siteMap {
 		if page.Path == path {
-			logger.Debug("Found page in sitemap: %s", path)
+			compilerLogger.Debug("Found page in sitemap: %s", path)
 			return &page, nil
 		}
 	}
 
-	logger.Warn("Page not found in sitemap: %s", path)
-	return nil, errors.New("page not found in site map")
+	compilerLogger.Warn("Page not found in sitemap: %s", path)
+	return nil, ErrPageNotFound
 }
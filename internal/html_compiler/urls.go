@@ -0,0 +1,136 @@
+package htmlcompiler
+
+import (
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+var hrefPattern = regexp.MustCompile(`href="([^"]*)"`)
+
+// Slugify formats a file or directory name as a URL segment following the slugify policy
+func Slugify(name string, policy config.SlugifyPolicy) string {
+	switch policy {
+	case config.SlugifyLowercase:
+		return strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+	case config.SlugifyDash:
+		var slug strings.Builder
+		separate := false
+		for _, r := range strings.ToLower(name) {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				separate = slug.Len() > 0
+				continue
+			}
+			if separate {
+				slug.WriteByte('-')
+				separate = false
+			}
+			slug.WriteRune(r)
+		}
+		return slug.String()
+	default:
+		return strings.ReplaceAll(name, " ", "_")
+	}
+}
+
+// GetPagePath returns the site map path of a markdown file from its path
+// relative to the content directory. Each segment is slugified, the .md
+// extension and a trailing /index are removed. The url metadata replaces the
+// whole path and the slug metadata the last segment
+func GetPagePath(relPath string, metadata *Metadata, policy config.SlugifyPolicy) string {
+	if metadata != nil && strings.TrimSpace(metadata.URL) != "" {
+		pagePath := strings.Trim(path.Clean("/"+strings.TrimSpace(metadata.URL)), "/")
+		if pagePath == "" {
+			return "index"
+		}
+		return pagePath
+	}
+
+	segments := strings.Split(strings.TrimSuffix(filepath.ToSlash(relPath), ".md"), "/")
+	if len(segments) > 1 && segments[len(segments)-1] == "index" {
+		segments = segments[:len(segments)-1]
+	}
+	isRootIndex := len(segments) == 1 && segments[0] == "index"
+
+	for i, segment := range segments {
+		segments[i] = Slugify(segment, policy)
+	}
+
+	if metadata != nil && !isRootIndex {
+		if slug := strings.Trim(strings.TrimSpace(metadata.Slug), "/"); slug != "" {
+			segments[len(segments)-1] = slug
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// FormatURL returns the canonical URL path of a site map path following the
+// trailing slash policy. The root index page is served at "/"
+func FormatURL(pagePath string, policy config.TrailingSlashPolicy) string {
+	if pagePath == "index" || pagePath == "" {
+		return "/"
+	}
+	urlPath := "/" + strings.Trim(pagePath, "/")
+	if policy == config.TrailingSlashAlways {
+		urlPath += "/"
+	}
+	return urlPath
+}
+
+// EscapeURLPath percent-encodes a URL path for use outside of HTML templates,
+// which escape URLs themselves (ex. sitemap.xml, llms.txt)
+func EscapeURLPath(urlPath string) string {
+	return (&url.URL{Path: urlPath}).EscapedPath()
+}
+
+// getFilePageURL returns the canonical URL of the page compiled from a markdown file
+func getFilePageURL(contentPath string, file string, urls config.URLs) (string, error) {
+	relPath, err := filepath.Rel(contentPath, file)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := GetFileMetadata(file)
+	if err != nil && !os.IsNotExist(err) {
+		compilerLogger.Warn("failed to get metadata for file %s: %v", file, err)
+	}
+
+	return FormatURL(GetPagePath(relPath, metadata, urls.Slugify), urls.TrailingSlash), nil
+}
+
+// replacePageLinks rewrites links to markdown files of the content directory
+// to the URLs of their pages. Relative links resolve from the directory of
+// filePath and absolute links from the content directory
+func replacePageLinks(htmlContent string, filePath string, contentPath string, urls config.URLs) string {
+	return hrefPattern.ReplaceAllStringFunc(htmlContent, func(match string) string {
+		href := html.UnescapeString(hrefPattern.FindStringSubmatch(match)[1])
+		link, err := url.Parse(href)
+		if err != nil || link.Scheme != "" || link.Host != "" || !strings.HasSuffix(link.Path, ".md") {
+			return match
+		}
+
+		target := filepath.Join(filepath.Dir(filePath), filepath.FromSlash(link.Path))
+		if strings.HasPrefix(link.Path, "/") {
+			target = filepath.Join(contentPath, filepath.FromSlash(link.Path))
+		}
+		if relPath, err := filepath.Rel(contentPath, target); err != nil || strings.HasPrefix(relPath, "..") {
+			return match
+		}
+
+		pageURL, err := getFilePageURL(contentPath, target, urls)
+		if err != nil {
+			return match
+		}
+
+		rewritten := url.URL{Path: pageURL, RawQuery: link.RawQuery, Fragment: link.Fragment}
+		return `href="` + html.EscapeString(rewritten.String()) + `"`
+	})
+}
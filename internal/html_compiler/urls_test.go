@@ -0,0 +1,104 @@
+package htmlcompiler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.SlugifyPolicy
+		want   string
+	}{
+		{"My First Post", config.SlugifyPreserve, "My_First_Post"},
+		{"My First Post", config.SlugifyLowercase, "my_first_post"},
+		{"My First Post", config.SlugifyDash, "my-first-post"},
+		{"  C++ & Go: a tour!  ", config.SlugifyDash, "c-go-a-tour"},
+		{"Élan_Vital--2024", config.SlugifyDash, "élan-vital-2024"},
+		{"日本語 ページ", config.SlugifyDash, "日本語-ページ"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.name, tt.policy); got != tt.want {
+			t.Errorf("Slugify(%q, %s) = %q, want %q", tt.name, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestGetPagePath(t *testing.T) {
+	tests := []struct {
+		relPath  string
+		metadata *Metadata
+		policy   config.SlugifyPolicy
+		want     string
+	}{
+		{"index.md", nil, config.SlugifyPreserve, "index"},
+		{"blog/index.md", nil, config.SlugifyPreserve, "blog"},
+		{"blog/posts/My Post.md", nil, config.SlugifyPreserve, "blog/posts/My_Post"},
+		{"Blog/Posts/My Post.md", nil, config.SlugifyDash, "blog/posts/my-post"},
+		{"blog/posts/My Post.md", &Metadata{Slug: "hello"}, config.SlugifyDash, "blog/posts/hello"},
+		{"blog/index.md", &Metadata{Slug: "articles"}, config.SlugifyPreserve, "articles"},
+		{"index.md", &Metadata{Slug: "home"}, config.SlugifyPreserve, "index"},
+		{"blog/posts/My Post.md", &Metadata{Slug: "ignored", URL: "/hello/world/"}, config.SlugifyPreserve, "hello/world"},
+		{"about.md", &Metadata{URL: "/"}, config.SlugifyPreserve, "index"},
+	}
+	for _, tt := range tests {
+		if got := GetPagePath(tt.relPath, tt.metadata, tt.policy); got != tt.want {
+			t.Errorf("GetPagePath(%q, %+v, %s) = %q, want %q", tt.relPath, tt.metadata, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestFormatURL(t *testing.T) {
+	tests := []struct {
+		pagePath string
+		policy   config.TrailingSlashPolicy
+		want     string
+	}{
+		{"index", config.TrailingSlashAlways, "/"},
+		{"blog/post", config.TrailingSlashIgnore, "/blog/post"},
+		{"blog/post", config.TrailingSlashNever, "/blog/post"},
+		{"blog/post", config.TrailingSlashAlways, "/blog/post/"},
+	}
+	for _, tt := range tests {
+		if got := FormatURL(tt.pagePath, tt.policy); got != tt.want {
+			t.Errorf("FormatURL(%q, %s) = %q, want %q", tt.pagePath, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestReplacePageLinks(t *testing.T) {
+	contentPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(contentPath, "blog", "posts"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]string{
+		"blog/posts/First Post.md": "# First\n",
+		"blog/posts/second.md":     "<!--\n{\"slug\": \"the-second\"}\n-->\n# Second\n",
+	}
+	for name, content := range pages {
+		if err := os.WriteFile(filepath.Join(contentPath, filepath.FromSlash(name)), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	urls := config.URLs{Slugify: config.SlugifyDash, TrailingSlash: config.TrailingSlashAlways}
+	filePath := filepath.Join(contentPath, "blog", "posts", "second.md")
+
+	tests := map[string]string{
+		`<a href="First%20Post.md#intro">`:             `<a href="/blog/posts/first-post/#intro">`,
+		`<a href="/blog/posts/second.md?a=1&amp;b=2">`: `<a href="/blog/posts/the-second/?a=1&amp;b=2">`,
+		`<a href="../../missing page.md">`:             `<a href="/missing-page/">`,
+		`<a href="../../../outside.md">`:               `<a href="../../../outside.md">`,
+		`<a href="https://example.com/readme.md">`:     `<a href="https://example.com/readme.md">`,
+		`<a href="#section">`:                          `<a href="#section">`,
+	}
+	for input, want := range tests {
+		if got := replacePageLinks(input, filePath, contentPath, urls); got != want {
+			t.Errorf("replacePageLinks(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
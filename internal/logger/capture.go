@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxCapturedMessages caps the messages kept per level by a Capture
+const maxCapturedMessages = 100
+
+// key of the field tagging the loggers of a Capture, its type keeps it out of the log lines
+const captureFieldKey = "capture"
+
+// Capture collects the warnings and errors logged while it is active by the
+// loggers tagged with it, ex. those of a build so they can be shown with it.
+// Loggers are tagged by Logger.Ctx with a context returned by Context
+type Capture struct {
+	mu       sync.Mutex
+	stopped  bool
+	warnings []string
+	errors   []string
+}
+
+type captureKey struct{}
+
+// StartCapture starts collecting the warnings and errors of the loggers tagged with the capture
+func StartCapture() *Capture {
+	return &Capture{}
+}
+
+// Context returns a copy of ctx carrying the capture, the loggers returned by
+// Logger.Ctx for it log to the capture
+func (c *Capture) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, captureKey{}, c)
+}
+
+// CaptureFromContext returns the capture carried by ctx, nil without one
+func CaptureFromContext(ctx context.Context) *Capture {
+	capture, _ := ctx.Value(captureKey{}).(*Capture)
+	return capture
+}
+
+// Ctx returns the logger tagged with the capture of ctx, or the logger itself
+// when ctx carries none
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	capture := CaptureFromContext(ctx)
+	if capture == nil {
+		return l
+	}
+	return &Logger{
+		sugar: l.sugar.Desugar().With(zap.Field{
+			Key:       captureFieldKey,
+			Type:      zapcore.SkipType,
+			Interface: capture,
+		}).Sugar(),
+	}
+}
+
+// Stop stops collecting and returns the warnings and errors logged since
+// StartCapture. It can be called more than once, and on a nil capture
+func (c *Capture) Stop() (warnings []string, errors []string) {
+	if c == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	return c.warnings, c.errors
+}
+
+func (c *Capture) active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.stopped
+}
+
+func (c *Capture) add(entry zapcore.Entry) {
+	message := entry.Message
+	if entry.LoggerName != "" {
+		message = entry.LoggerName + ": " + message
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return
+	}
+	if entry.Level == zapcore.WarnLevel {
+		if len(c.warnings) < maxCapturedMessages {
+			c.warnings = append(c.warnings, message)
+		}
+	} else if len(c.errors) < maxCapturedMessages {
+		c.errors = append(c.errors, message)
+	}
+}
+
+// captureCore is the zap core forwarding the warnings and errors of the
+// loggers tagged with a capture to it
+type captureCore struct {
+	capture *Capture
+}
+
+func (c captureCore) Enabled(level zapcore.Level) bool {
+	return c.capture != nil && level >= zapcore.WarnLevel
+}
+
+func (c captureCore) With(fields []zapcore.Field) zapcore.Core {
+	for _, field := range fields {
+		if field.Key != captureFieldKey || field.Type != zapcore.SkipType {
+			continue
+		}
+		if capture, ok := field.Interface.(*Capture); ok {
+			c.capture = capture
+		}
+	}
+	return c
+}
+
+func (c captureCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) || !c.capture.active() {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+func (c captureCore) Write(entry zapcore.Entry, _ []zapcore.Field) error {
+	c.capture.add(entry)
+	return nil
+}
+
+func (captureCore) Sync() error {
+	return nil
+}
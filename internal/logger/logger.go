@@ -1,10 +1,17 @@
 package logger
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type LogLevel int
@@ -76,13 +83,125 @@ func toZapLevel(level LogLevel) zapcore.Level {
 	}
 }
 
-var globalLogger *zap.SugaredLogger
+// encodings of the log lines
+const (
+	EncodingConsole = "console"
+	EncodingJSON    = "json"
+)
+
+// sinks written to instead of a file
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Config configures the encoding, outputs and levels of every logger
+type Config struct {
+	// minimum level of the services without an override
+	Level LogLevel
+	// EncodingConsole or EncodingJSON
+	Encoding string
+	// OutputStdout, OutputStderr or file paths, every line is written to each
+	Outputs  []string
+	Rotation Rotation
+	// minimum level by service name, ex. git, overriding Level
+	ServiceLevels map[string]LogLevel
+}
+
+// Rotation configures the rotation of the log files
+type Rotation struct {
+	// size in megabytes at which a file is rotated, 100 when 0
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// days rotated files are kept, forever when 0
+	MaxAgeDays int `yaml:"max_age_days"`
+	// number of rotated files kept, all when 0
+	MaxBackups int  `yaml:"max_backups"`
+	Compress   bool `yaml:"compress"`
+}
+
+// output is the part of Config that needs the sinks to be opened again when it changes
+type output struct {
+	encoding string
+	outputs  string
+	rotation Rotation
+}
+
+var (
+	// every logger writes through the dynamic core, so configuring the
+	// outputs also applies to the loggers already in use
+	globalLogger = zap.New(
+		dynamicCore{},
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	).Sugar()
+	activeCore atomic.Pointer[zapcore.Core]
+	// shared by every logger, so Init changes the level of the loggers already in use
+	globalLevel   = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	serviceLevels atomic.Pointer[map[string]zapcore.Level]
+
+	initMu      sync.Mutex
+	initialized bool
+	// output of the active core and the files it writes to, closed when it is replaced
+	activeOutput output
+	openFiles    []io.Closer
+)
 
 type Logger struct {
 	sugar *zap.SugaredLogger
 }
 
+// Init sets the minimum level of the loggers. The first call of Init or
+// Configure builds the default console logger writing to stdout, later calls
+// only change the level
 func Init(minLevel LogLevel) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	globalLevel.SetLevel(toZapLevel(minLevel))
+	if initialized {
+		return nil
+	}
+	return configureOutput(output{encoding: EncodingConsole, outputs: OutputStdout})
+}
+
+// Configure applies the encoding, outputs, rotation and levels of config to
+// every logger. The outputs are only opened again when they changed
+func Configure(config Config) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	if config.Encoding == "" {
+		config.Encoding = EncodingConsole
+	}
+	if len(config.Outputs) == 0 {
+		config.Outputs = []string{OutputStdout}
+	}
+	if config.Encoding != EncodingConsole && config.Encoding != EncodingJSON {
+		return fmt.Errorf("invalid log encoding %q, must be %q or %q", config.Encoding, EncodingConsole, EncodingJSON)
+	}
+
+	levels := make(map[string]zapcore.Level, len(config.ServiceLevels))
+	for service, level := range config.ServiceLevels {
+		levels[strings.ToLower(service)] = toZapLevel(level)
+	}
+	serviceLevels.Store(&levels)
+	globalLevel.SetLevel(toZapLevel(config.Level))
+
+	newOutput := output{
+		encoding: config.Encoding,
+		outputs:  strings.Join(config.Outputs, "\n"),
+		rotation: config.Rotation,
+	}
+	if initialized && newOutput == activeOutput {
+		return nil
+	}
+	return configureOutput(newOutput)
+}
+
+// configureOutput opens the sinks of out and makes them the active core
+func configureOutput(out output) error {
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
 		LevelKey:       "level",
@@ -98,26 +217,150 @@ func Init(minLevel LogLevel) error {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	config := zap.Config{
-		Level:            zap.NewAtomicLevelAt(toZapLevel(minLevel)),
-		Development:      false,
-		Encoding:         "console",
-		EncoderConfig:    encoderConfig,
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
+	var encoder zapcore.Encoder
+	if out.encoding == EncodingJSON {
+		encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
 	}
 
-	logger, err := config.Build(zap.AddCallerSkip(1))
-	if err != nil {
-		return err
+	var sinks []zapcore.WriteSyncer
+	var files []io.Closer
+	for _, path := range strings.Split(out.outputs, "\n") {
+		switch path {
+		case OutputStdout:
+			sinks = append(sinks, zapcore.Lock(os.Stdout))
+		case OutputStderr:
+			sinks = append(sinks, zapcore.Lock(os.Stderr))
+		default:
+			file := &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    out.rotation.MaxSizeMB,
+				MaxAge:     out.rotation.MaxAgeDays,
+				MaxBackups: out.rotation.MaxBackups,
+				Compress:   out.rotation.Compress,
+			}
+			sinks = append(sinks, zapcore.AddSync(file))
+			files = append(files, file)
+		}
 	}
 
-	globalLogger = logger.Sugar()
+	// the levels are checked by serviceLevelCore, the sinks write every entry it lets through
+	core := zapcore.NewTee(
+		serviceLevelCore{zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(sinks...), zapcore.DebugLevel)},
+		captureCore{},
+	)
+	activeCore.Store(&core)
+
+	for _, file := range openFiles {
+		_ = file.Close()
+	}
+	openFiles = files
+	activeOutput = out
+	initialized = true
 	return nil
 }
 
+// levelOf returns the minimum level of a logger name. Names of nested
+// services, ex. Main.git, use the override of their most specific service
+func levelOf(name string) zapcore.Level {
+	levels := serviceLevels.Load()
+	if levels == nil || len(*levels) == 0 || name == "" {
+		return globalLevel.Level()
+	}
+
+	name = strings.ToLower(name)
+	if level, ok := (*levels)[name]; ok {
+		return level
+	}
+	services := strings.Split(name, ".")
+	for i := len(services) - 1; i >= 0; i-- {
+		if level, ok := (*levels)[services[i]]; ok {
+			return level
+		}
+	}
+	return globalLevel.Level()
+}
+
+// minLevel returns the lowest level enabled for any service
+func minLevel() zapcore.Level {
+	level := globalLevel.Level()
+	if levels := serviceLevels.Load(); levels != nil {
+		for _, serviceLevel := range *levels {
+			level = min(level, serviceLevel)
+		}
+	}
+	return level
+}
+
+// serviceLevelCore filters the entries by the level of their service
+type serviceLevelCore struct {
+	zapcore.Core
+}
+
+func (c serviceLevelCore) Enabled(level zapcore.Level) bool {
+	return level >= minLevel()
+}
+
+func (c serviceLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return serviceLevelCore{c.Core.With(fields)}
+}
+
+func (c serviceLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < levelOf(entry.LoggerName) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// dynamicCore writes to the active core, adding the fields of With
+type dynamicCore struct {
+	fields []zapcore.Field
+}
+
+func (c dynamicCore) core() zapcore.Core {
+	core := activeCore.Load()
+	if core == nil {
+		// logging before Init or Configure uses the default console logger
+		if err := Init(InfoLevel); err != nil {
+			return zapcore.NewNopCore()
+		}
+		core = activeCore.Load()
+	}
+	if len(c.fields) == 0 {
+		return *core
+	}
+	return (*core).With(c.fields)
+}
+
+func (c dynamicCore) Enabled(level zapcore.Level) bool {
+	// the fields do not change the levels, no need to add them
+	return dynamicCore{}.core().Enabled(level)
+}
+
+func (c dynamicCore) With(fields []zapcore.Field) zapcore.Core {
+	return dynamicCore{fields: append(slices.Clip(c.fields), fields...)}
+}
+
+func (c dynamicCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.core().Check(entry, checked)
+}
+
+func (c dynamicCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.core().Write(entry, fields)
+}
+
+func (c dynamicCore) Sync() error {
+	return c.core().Sync()
+}
+
 func New(service string, minLevel LogLevel) *Logger {
-	if globalLogger == nil {
+	initMu.Lock()
+	isInitialized := initialized
+	initMu.Unlock()
+	if !isInitialized {
 		if err := Init(minLevel); err != nil {
 			panic(err)
 		}
@@ -128,6 +371,14 @@ func New(service string, minLevel LogLevel) *Logger {
 	}
 }
 
+// Service returns the logger of a package, ex. git, named after the service
+// so its level can be overridden. Unlike New it does not change the level
+func Service(service string) *Logger {
+	return &Logger{
+		sugar: globalLogger.Named(service),
+	}
+}
+
 func (l *Logger) Debug(format string, args ...interface{}) {
 	l.sugar.Debugf(format, args...)
 }
@@ -154,41 +405,35 @@ func (l *Logger) WithService(service string) *Logger {
 	}
 }
 
-func Debug(format string, args ...interface{}) {
-	if globalLogger != nil {
-		globalLogger.Debugf(format, args...)
+// With returns a logger adding the key value pairs to its lines, ex. the request ID
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return &Logger{
+		sugar: l.sugar.With(keysAndValues...),
 	}
 }
 
+func Debug(format string, args ...interface{}) {
+	globalLogger.Debugf(format, args...)
+}
+
 func Info(format string, args ...interface{}) {
-	if globalLogger != nil {
-		globalLogger.Infof(format, args...)
-	}
+	globalLogger.Infof(format, args...)
 }
 
 func Warn(format string, args ...interface{}) {
-	if globalLogger != nil {
-		globalLogger.Warnf(format, args...)
-	}
+	globalLogger.Warnf(format, args...)
 }
 
 func Error(format string, args ...interface{}) {
-	if globalLogger != nil {
-		globalLogger.Errorf(format, args...)
-	}
+	globalLogger.Errorf(format, args...)
 }
 
 func Fatal(format string, args ...interface{}) {
-	if globalLogger != nil {
-		globalLogger.Fatalf(format, args...)
-	}
+	globalLogger.Fatalf(format, args...)
 }
 
 func Sync() error {
-	if globalLogger != nil {
-		return globalLogger.Sync()
-	}
-	return nil
+	return globalLogger.Sync()
 }
 
 type CronLoggerAdapter struct {
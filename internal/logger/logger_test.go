@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "mdserve.log")
+	err := Configure(Config{
+		Level:         InfoLevel,
+		Encoding:      EncodingJSON,
+		Outputs:       []string{logPath},
+		ServiceLevels: map[string]LogLevel{"git": DebugLevel, "Compiler": WarnLevel},
+	})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = Configure(Config{Level: InfoLevel})
+	})
+
+	// loggers created before Configure write to the new outputs too
+	main := New("Main", InfoLevel)
+	git := Service("git")
+	compiler := Service("compiler")
+
+	main.Debug("main debug")
+	main.Info("main info")
+	git.Debug("git debug")
+	main.WithService("git").Debug("nested git debug")
+	compiler.Info("compiler info")
+	compiler.Warn("compiler warn")
+	main.With("request_id", "abc").Info("with fields")
+	if err := Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	var messages []string
+	fields := map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		message, _ := entry["msg"].(string)
+		messages = append(messages, message)
+		if message == "with fields" {
+			fields = entry
+		}
+	}
+
+	want := []string{"main info", "git debug", "nested git debug", "compiler warn", "with fields"}
+	if strings.Join(messages, ",") != strings.Join(want, ",") {
+		t.Errorf("logged %q, want %q", messages, want)
+	}
+	if fields["request_id"] != "abc" || fields["service"] != "Main" {
+		t.Errorf("fields = %v, want request_id abc and service Main", fields)
+	}
+}
+
+func TestConfigureInvalidEncoding(t *testing.T) {
+	if err := Configure(Config{Encoding: "xml"}); err == nil {
+		t.Error("Configure() with an unknown encoding succeeded")
+	}
+}
+
+func TestCapture(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "mdserve.log")
+	if err := Configure(Config{Level: InfoLevel, Encoding: EncodingJSON, Outputs: []string{logPath}}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = Configure(Config{Level: InfoLevel})
+	})
+
+	capture := StartCapture()
+	ctx := capture.Context(context.Background())
+	main := New("Main", InfoLevel)
+	compiler := Service("compiler")
+
+	main.Ctx(ctx).Warn("build warn")
+	compiler.Ctx(ctx).With("file", "a.md").Error("build error")
+	main.Ctx(ctx).Info("build info")
+	// loggers of other requests are not tagged with the capture
+	main.Warn("request warn")
+	compiler.Ctx(context.Background()).Error("request error")
+
+	warnings, errors := capture.Stop()
+	main.Ctx(ctx).Warn("after stop")
+	if strings.Join(warnings, ",") != "Main: build warn" {
+		t.Errorf("warnings = %q, want the build warning", warnings)
+	}
+	if strings.Join(errors, ",") != "compiler: build error" {
+		t.Errorf("errors = %q, want the build error", errors)
+	}
+	if CaptureFromContext(context.Background()) != nil {
+		t.Error("a context without a capture returned one")
+	}
+
+	if err := Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), captureFieldKey) {
+		t.Errorf("the capture tag was logged: %s", content)
+	}
+}
@@ -0,0 +1,139 @@
+// Package metrics records the metrics of the server, served in the
+// Prometheus text format by Handler when metrics_enabled is set
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/observe"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mdserve"
+
+// build phases
+const (
+	PhaseGitSync           = "git_sync"
+	PhaseCompile           = "compile"
+	PhaseSiteMap           = "sitemap"
+	PhaseImageOptimization = "image_optimization"
+	PhasePrecompression    = "precompression"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route class and status.",
+	}, []string{"route", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by route class and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "status"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_cache_requests_total",
+		Help:      "Conditional HTTP requests by route class, a hit is answered with 304 Not Modified.",
+	}, []string{"route", "result"})
+
+	liveCompileDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "live_compile_duration_seconds",
+		Help:      "Duration of the markdown compilations of the live compilation mode.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	buildPhaseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "build_phase_duration_seconds",
+		Help:      "Duration of the phases of the builds: git_sync, compile, sitemap, image_optimization and precompression.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"phase"})
+
+	webPBytesSaved = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webp_bytes_saved_total",
+		Help:      "Bytes saved by converting images to WebP.",
+	})
+
+	gitSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "git_syncs_total",
+		Help:      "Git syncs of the content, templates and assets by result.",
+	}, []string{"result"})
+
+	// registered by the first sync, so sites without git syncs do not report a failure
+	gitSyncSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "git_sync_success",
+		Help:      "Whether the last git sync succeeded (1) or failed (0).",
+	})
+	registerGitSyncSuccess sync.Once
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count, latency and cache result of the requests served by next
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := observe.NewResponseRecorder(w)
+		next.ServeHTTP(rw, r)
+
+		route := observe.RouteClass(r.URL.Path)
+		status := strconv.Itoa(rw.Status())
+		requests.WithLabelValues(route, status).Inc()
+		requestDuration.WithLabelValues(route, status).Observe(time.Since(start).Seconds())
+
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			result := "miss"
+			if rw.Status() == http.StatusNotModified {
+				result = "hit"
+			}
+			cacheRequests.WithLabelValues(route, result).Inc()
+		}
+	})
+}
+
+// ObserveLiveCompile records the duration of a markdown compilation of the live mode
+func ObserveLiveCompile(duration time.Duration) {
+	liveCompileDuration.Observe(duration.Seconds())
+}
+
+// ObserveBuildPhase records the duration of a phase of a build
+func ObserveBuildPhase(phase string, duration time.Duration) {
+	buildPhaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// AddWebPBytesSaved records the bytes saved by converting an image to WebP
+func AddWebPBytesSaved(bytes int64) {
+	if bytes > 0 {
+		webPBytesSaved.Add(float64(bytes))
+	}
+}
+
+// RecordGitSync records the result of a git sync
+func RecordGitSync(err error) {
+	registerGitSyncSuccess.Do(func() {
+		prometheus.MustRegister(gitSyncSuccess)
+	})
+	if err != nil {
+		gitSyncs.WithLabelValues("failure").Inc()
+		gitSyncSuccess.Set(0)
+		return
+	}
+	gitSyncs.WithLabelValues("success").Inc()
+	gitSyncSuccess.Set(1)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Path == "/api/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	serve := func(path string, etag string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve("/api/missing", "")
+	serve("/api/pages", `"v1"`)
+	serve("/api/pages", `"v0"`)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`mdserve_http_requests_total{route="api",status="404"} 1`,
+		`mdserve_http_requests_total{route="api",status="304"} 1`,
+		`mdserve_http_requests_total{route="api",status="200"} 1`,
+		`mdserve_http_request_duration_seconds_count{route="api",status="404"} 1`,
+		`mdserve_http_cache_requests_total{result="hit",route="api"} 1`,
+		`mdserve_http_cache_requests_total{result="miss",route="api"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestRecordGitSync(t *testing.T) {
+	gather := func() string {
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	if strings.Contains(gather(), "mdserve_git_sync_success") {
+		t.Error("git sync result reported before any sync")
+	}
+
+	RecordGitSync(errors.New("connection refused"))
+	RecordGitSync(nil)
+	body := gather()
+	for _, want := range []string{
+		`mdserve_git_sync_success 1`,
+		`mdserve_git_syncs_total{result="failure"} 1`,
+		`mdserve_git_syncs_total{result="success"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package observe holds what the metrics, traces and access log of the server
+// record of each request: its route class and the status and size of its
+// response
+package observe
+
+import (
+	"net/http"
+	"strings"
+)
+
+// route classes of the requests
+const (
+	RoutePage    = "page"
+	RouteAsset   = "asset"
+	RouteSiteMap = "sitemap"
+	RouteAPI     = "api"
+	RouteOther   = "other"
+)
+
+// RouteClass returns the route class of a request path
+func RouteClass(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/assets/"), strings.HasPrefix(path, "/user-static/"):
+		return RouteAsset
+	case path == "/sitemap.xml", strings.HasPrefix(path, "/sitemap/"):
+		return RouteSiteMap
+	case strings.HasPrefix(path, "/api/"):
+		return RouteAPI
+	case path == "/robots.txt", path == "/llms.txt",
+		strings.HasPrefix(path, "/admin/"), strings.HasPrefix(path, "/hooks/"),
+		strings.HasPrefix(path, "/_mdserve/"):
+		return RouteOther
+	default:
+		return RoutePage
+	}
+}
+
+// ResponseRecorder records the status and size of a response written through it
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// NewResponseRecorder returns a ResponseRecorder writing to w
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the status of the response, 200 until one is written
+func (rw *ResponseRecorder) Status() int {
+	return rw.status
+}
+
+// Bytes returns the number of bytes of the body written so far
+func (rw *ResponseRecorder) Bytes() int {
+	return rw.bytes
+}
+
+func (rw *ResponseRecorder) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *ResponseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, ex. to
+// flush the reload events of the watch mode
+func (rw *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,50 @@
+package observe
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteClass(t *testing.T) {
+	tests := map[string]string{
+		"/":                       RoutePage,
+		"/blog/posts/md":          RoutePage,
+		"/archive/2025/":          RoutePage,
+		"/assets/logo.webp":       RouteAsset,
+		"/user-static/file.pdf":   RouteAsset,
+		"/sitemap.xml":            RouteSiteMap,
+		"/sitemap/sitemap-1.xml":  RouteSiteMap,
+		"/api/pages":              RouteAPI,
+		"/robots.txt":             RouteOther,
+		"/admin/builds":           RouteOther,
+		"/hooks/git":              RouteOther,
+		"/_mdserve/reload":        RouteOther,
+		"/assets-and-more/page":   RoutePage,
+		"/api-documentation/page": RoutePage,
+	}
+	for path, want := range tests {
+		if got := RouteClass(path); got != want {
+			t.Errorf("RouteClass(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestResponseRecorder(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	if rec.Status() != http.StatusOK {
+		t.Errorf("status before a response = %d, want %d", rec.Status(), http.StatusOK)
+	}
+
+	rec.WriteHeader(http.StatusNotFound)
+	rec.WriteHeader(http.StatusInternalServerError)
+	_, _ = io.WriteString(rec, "not found")
+
+	if rec.Status() != http.StatusNotFound {
+		t.Errorf("status = %d, want the first one written %d", rec.Status(), http.StatusNotFound)
+	}
+	if rec.Bytes() != len("not found") {
+		t.Errorf("bytes = %d, want %d", rec.Bytes(), len("not found"))
+	}
+}
@@ -0,0 +1,116 @@
+// Package tracing traces the requests and builds of the server with
+// OpenTelemetry, exported over OTLP/HTTP when tracing_enabled is set. Until
+// Setup is called the spans are no-ops
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jaysongiroux/mdserve/internal/observe"
+	"github.com/jaysongiroux/mdserve/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "github.com/jaysongiroux/mdserve"
+	serviceName = "mdserve"
+	// path of the traces of an OTLP/HTTP endpoint given without a path
+	tracesPath = "/v1/traces"
+)
+
+// Setup exports the spans to the OTLP/HTTP endpoint, ex.
+// http://localhost:4318, or to the endpoint of the OTEL_EXPORTER_OTLP_*
+// environment variables when empty. The W3C trace context of the requests is
+// propagated. The returned shutdown flushes the pending spans
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	var options []otlptracehttp.Option
+	if endpoint != "" {
+		endpointURL, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+		}
+		if endpointURL.Path == "" || endpointURL.Path == "/" {
+			endpointURL.Path = tracesPath
+		}
+		options = append(options, otlptracehttp.WithEndpointURL(endpointURL.String()))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(version.Get()),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the tracing resource: %w", err)
+	}
+
+	// the sampler honors OTEL_TRACES_SAMPLER, all traces are sampled by default
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name, a child of the span of ctx
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends span, recording err as its error when not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware serves the requests of next in server spans, children of the
+// W3C trace context of the requests when they carry one
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := observe.RouteClass(r.URL.Path)
+		ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("mdserve.route", route),
+			),
+		)
+		defer span.End()
+
+		rw := observe.NewResponseRecorder(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.Status()))
+		if rw.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.Status()))
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an in-process OTLP/HTTP collector keeping the spans it receives
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != tracesPath {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+	c.mu.Unlock()
+
+	response, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(response)
+}
+
+func (c *collector) span(name string) *tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, span := range c.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestMiddlewareExportsSpans(t *testing.T) {
+	collector := &collector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	shutdown, err := Setup(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "template.execute")
+		End(span, errors.New("template failed"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/docs/page", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	serverSpan := collector.span("GET page")
+	if serverSpan == nil {
+		t.Fatalf("server span not exported, got %v", collector.spans)
+	}
+	if got := hex.EncodeToString(serverSpan.TraceId); got != traceID {
+		t.Errorf("server span trace ID = %s, want the propagated %s", got, traceID)
+	}
+	if got := hex.EncodeToString(serverSpan.ParentSpanId); got != parentSpanID {
+		t.Errorf("server span parent = %s, want the propagated %s", got, parentSpanID)
+	}
+	if serverSpan.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("server span kind = %v, want server", serverSpan.Kind)
+	}
+	if serverSpan.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("server span status = %v, want error", serverSpan.Status.GetCode())
+	}
+
+	childSpan := collector.span("template.execute")
+	if childSpan == nil {
+		t.Fatal("child span not exported")
+	}
+	if hex.EncodeToString(childSpan.TraceId) != traceID {
+		t.Errorf("child span is not in the propagated trace")
+	}
+	if hex.EncodeToString(childSpan.ParentSpanId) != hex.EncodeToString(serverSpan.SpanId) {
+		t.Errorf("child span is not a child of the server span")
+	}
+	if childSpan.Status.GetMessage() != "template failed" {
+		t.Errorf("child span status message = %q, want the error", childSpan.Status.GetMessage())
+	}
+}
+
+func TestStartWithoutSetupIsNoop(t *testing.T) {
+	_, span := Start(context.Background(), "build")
+	if span.SpanContext().IsValid() {
+		t.Error("span without Setup has a valid span context, want a no-op span")
+	}
+	End(span, nil)
+}
@@ -0,0 +1,30 @@
+// Package version reports the version of the mdserve binary
+package version
+
+import "runtime/debug"
+
+// Version is set at build time, ex.
+// go build -ldflags "-X github.com/jaysongiroux/mdserve/internal/version.Version=v1.2.3"
+var Version = ""
+
+// Get returns Version, or else the module version or VCS revision the Go
+// toolchain recorded in the binary, or "dev"
+func Get() string {
+	if Version != "" {
+		return Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "dev"
+}
@@ -0,0 +1,221 @@
+// Package watch reports changes to the sources of the site (content,
+// templates, assets, user-static and the configs) for the serve --watch mode.
+// Bursts of file system events, ex. an editor saving several files, are
+// reported as a single set of changes
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jaysongiroux/mdserve/internal/config"
+	"github.com/jaysongiroux/mdserve/internal/logger"
+)
+
+// watchLogger logs the messages of the watch service
+var watchLogger = logger.Service("watch")
+
+// quiet period after the last event before the changes are reported
+const debounceDelay = 200 * time.Millisecond
+
+// Change is a set of the kinds of sources that changed
+type Change int
+
+const (
+	ContentChanged Change = 1 << iota
+	TemplatesChanged
+	AssetsChanged
+	UserStaticChanged
+	ConfigChanged
+)
+
+// Has reports whether c includes any of the kinds of other
+func (c Change) Has(other Change) bool {
+	return c&other != 0
+}
+
+func (c Change) String() string {
+	var kinds []string
+	for _, kind := range []struct {
+		change Change
+		name   string
+	}{
+		{ContentChanged, "content"},
+		{TemplatesChanged, "templates"},
+		{AssetsChanged, "assets"},
+		{UserStaticChanged, "user-static"},
+		{ConfigChanged, "config"},
+	} {
+		if c.Has(kind.change) {
+			kinds = append(kinds, kind.name)
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// source is a watched directory or config file
+type source struct {
+	path   string
+	change Change
+	// config files are watched through their directory, so editors replacing
+	// the file on save are picked up
+	file bool
+}
+
+// Watcher watches the sources of the site
+type Watcher struct {
+	fsWatcher *fsnotify.Watcher
+	sources   []source
+}
+
+// New returns a Watcher of the content, templates, assets and user-static
+// directories of serverConfig and of configPaths
+func New(serverConfig *config.ServerConfig, configPaths []string) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	w := &Watcher{fsWatcher: fsWatcher}
+
+	for _, dir := range []source{
+		{path: serverConfig.ContentPath, change: ContentChanged},
+		{path: serverConfig.TemplatesPath, change: TemplatesChanged},
+		{path: serverConfig.AssetsPath, change: AssetsChanged},
+		{path: serverConfig.UserStaticPath, change: UserStaticChanged},
+	} {
+		dir.path = filepath.Clean(dir.path)
+		w.sources = append(w.sources, dir)
+		if err := w.addDirectory(dir.path); err != nil {
+			_ = fsWatcher.Close()
+			return nil, err
+		}
+	}
+
+	for _, configPath := range configPaths {
+		configPath = filepath.Clean(configPath)
+		w.sources = append(w.sources, source{path: configPath, change: ConfigChanged, file: true})
+		if err := fsWatcher.Add(filepath.Dir(configPath)); err != nil {
+			_ = fsWatcher.Close()
+			return nil, fmt.Errorf("failed to watch config %s: %w", configPath, err)
+		}
+	}
+
+	return w, nil
+}
+
+// Run reports the changes to onChange until the watcher is closed. onChange
+// is called from the goroutine of Run, events received meanwhile are reported
+// with the next call
+func (w *Watcher) Run(onChange func(Change)) {
+	var pending Change
+	timer := time.NewTimer(debounceDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			change := w.handleEvent(event)
+			if change == 0 {
+				continue
+			}
+			watchLogger.Debug("Watched file changed: %s %s", event.Op, event.Name)
+			pending |= change
+			timer.Reset(debounceDelay)
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			watchLogger.Warn("File watcher error: %v", err)
+		case <-timer.C:
+			change := pending
+			pending = 0
+			onChange(change)
+		}
+	}
+}
+
+// Close stops watching, Run returns
+func (w *Watcher) Close() error {
+	return w.fsWatcher.Close()
+}
+
+// handleEvent returns the kind of source changed by event, 0 when the event is
+// ignored. New directories are watched
+func (w *Watcher) handleEvent(event fsnotify.Event) Change {
+	if event.Op == fsnotify.Chmod || isEditorTempFile(event.Name) {
+		return 0
+	}
+
+	change := w.classify(event.Name)
+	if change == 0 || change == ConfigChanged {
+		return change
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addDirectory(event.Name); err != nil {
+				watchLogger.Warn("Failed to watch directory %s: %v", event.Name, err)
+			}
+		}
+	}
+	return change
+}
+
+// classify returns the kind of source holding path, 0 for unwatched paths
+func (w *Watcher) classify(path string) Change {
+	path = filepath.Clean(path)
+	for _, source := range w.sources {
+		if source.file {
+			if path == source.path {
+				return source.change
+			}
+			continue
+		}
+		relPath, err := filepath.Rel(source.path, path)
+		if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return source.change
+		}
+	}
+	return 0
+}
+
+// addDirectory watches dir and its subdirectories, fsnotify is not recursive.
+// Missing directories are skipped, ex. a site without user-static files
+func (w *Watcher) addDirectory(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".git") {
+			return filepath.SkipDir
+		}
+		return w.fsWatcher.Add(path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// isEditorTempFile reports whether path is a swap or backup file of an editor
+func isEditorTempFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") ||
+		strings.HasSuffix(name, ".swx") ||
+		strings.HasPrefix(name, ".#")
+}
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaysongiroux/mdserve/internal/config"
+)
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	serverConfig := &config.ServerConfig{
+		ContentPath:    filepath.Join(root, "content"),
+		TemplatesPath:  filepath.Join(root, "templates"),
+		AssetsPath:     filepath.Join(root, "assets"),
+		UserStaticPath: filepath.Join(root, "user-static"),
+	}
+	for _, dir := range []string{serverConfig.ContentPath, serverConfig.TemplatesPath, filepath.Join(root, "config")} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(root, "config", "config.yaml")
+
+	watcher, err := New(serverConfig, []string{configPath})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	changes := make(chan Change, 10)
+	go watcher.Run(func(change Change) {
+		changes <- change
+	})
+
+	write := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("changed"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(want Change) {
+		t.Helper()
+		select {
+		case change := <-changes:
+			if change != want {
+				t.Errorf("change = %s, want %s", change, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change reported, want %s", want)
+		}
+	}
+
+	write(filepath.Join(serverConfig.ContentPath, "post.md"))
+	write(filepath.Join(serverConfig.TemplatesPath, "layout.html"))
+	expect(ContentChanged | TemplatesChanged)
+
+	// directories created after the watcher are watched too
+	newDir := filepath.Join(serverConfig.ContentPath, "blog")
+	if err := os.Mkdir(newDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	expect(ContentChanged)
+	write(filepath.Join(newDir, "post.md"))
+	expect(ContentChanged)
+
+	// other files next to the configs are ignored
+	write(filepath.Join(root, "config", "notes.txt"))
+	write(configPath)
+	expect(ConfigChanged)
+}
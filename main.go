@@ -6,18 +6,21 @@ package main
 // and a cron logger adapter for cron jobs
 
 import (
+	"context"
 	"errors"
-	"html/template"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"regexp"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jaysongiroux/mdserve/internal/assets"
+	"github.com/jaysongiroux/mdserve/internal/build"
+	"github.com/jaysongiroux/mdserve/internal/compression"
 	"github.com/jaysongiroux/mdserve/internal/config"
 	"github.com/jaysongiroux/mdserve/internal/constants"
 	"github.com/jaysongiroux/mdserve/internal/demo"
@@ -26,11 +29,34 @@ import (
 	"github.com/jaysongiroux/mdserve/internal/handler"
 	htmlcompiler "github.com/jaysongiroux/mdserve/internal/html_compiler"
 	"github.com/jaysongiroux/mdserve/internal/logger"
+	"github.com/jaysongiroux/mdserve/internal/metrics"
+	"github.com/jaysongiroux/mdserve/internal/tracing"
+	"github.com/jaysongiroux/mdserve/internal/watch"
 	"github.com/joho/godotenv"
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func prelimSetup(callerName string) (*handler.App, error) {
+// errGitSync wraps the errors of the git syncs of prelimSetup, which may
+// succeed when retried
+var errGitSync = errors.New("git sync failed")
+
+// retry delays of a failed initial git sync, doubled after each attempt
+const (
+	initialSyncRetryDelay = 5 * time.Second
+	maxSyncRetryDelay     = time.Minute
+)
+
+// how long the requests in flight and the export of the remaining spans have
+// to finish when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// prelimSetup loads the configs and generates a new build of the site into
+// its own directory, which is validated and made live atomically. A failed
+// generation returns an error and leaves the live build untouched. Cancelling
+// ctx stops the git sync and the generation
+func prelimSetup(ctx context.Context, callerName string) (*handler.App, error) {
 	appLogger := logger.New("Initial Setup", logger.DebugLevel)
 
 	app := &handler.App{
@@ -39,6 +65,7 @@ func prelimSetup(callerName string) (*handler.App, error) {
 		Logger:                  nil,
 		Templates:               nil,
 		Handler:                 handler.HandlePage,
+		BuildPath:               "",
 		TemplatesGeneratedPath:  "",
 		AssetsGeneratedPath:     "",
 		UserStaticGeneratedPath: "",
@@ -46,19 +73,19 @@ func prelimSetup(callerName string) (*handler.App, error) {
 
 	serverConfig, err := config.LoadServerConfig()
 	if err != nil {
-		appLogger.Fatal("Failed to load server config: %v", err)
+		return nil, fmt.Errorf("failed to load server config: %w", err)
 	}
 	app.ServerConfig = serverConfig
 
 	siteConfig, err := config.LoadSiteConfig()
 	if err != nil {
-		appLogger.Fatal("Failed to load site config: %v", err)
+		return nil, fmt.Errorf("failed to load site config: %w", err)
 	}
 	app.SiteConfig = siteConfig
 
-	err = logger.Init(app.ServerConfig.LogLevel)
+	err = logger.Configure(app.ServerConfig.LoggingConfig())
 	if err != nil {
-		appLogger.Fatal("Failed to initialize logger: %v", err)
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
 	defer func() {
@@ -69,137 +96,560 @@ func prelimSetup(callerName string) (*handler.App, error) {
 				errors.Is(err, os.ErrInvalid) {
 				return
 			}
-			appLogger.Fatal("Failed to sync logger: %v", err)
+			appLogger.Error("Failed to sync logger: %v", err)
 		}
 	}()
 
-	appLogger = logger.New(callerName, app.ServerConfig.LogLevel)
+	appLogger = logger.New(callerName, app.ServerConfig.LogLevel).Ctx(ctx)
 	app.Logger = appLogger
 
 	if app.ServerConfig.Demo {
 		err = demo.HandleDemoEnabled(app)
 		if err != nil {
-			appLogger.Fatal("Failed to handle demo mode: %v", err)
+			return nil, fmt.Errorf("failed to handle demo mode: %w", err)
 		}
 	}
 
-	if app.ServerConfig.GitRemoteContentURL != "" {
-		err = git.HandleGitRemoteContent(app.ServerConfig)
-		if err != nil {
-			appLogger.Fatal("Failed to handle git remote content: %v", err)
+	err = syncFromGit(ctx, app.ServerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return generate(ctx, app, callerName, "", build.AllSteps)
+}
+
+// failedBuilds keeps the last failed builds for the admin API, their
+// directories are deleted
+var failedBuilds = build.NewFailures(maxFailedBuilds)
+
+const maxFailedBuilds = 20
+
+// syncFromGit pulls the git remote content and the templates and assets of
+// the git configs, when configured, and records the result in the metrics
+func syncFromGit(ctx context.Context, serverConfig *config.ServerConfig) error {
+	if serverConfig.GitRemoteContentURL == "" && !serverConfig.SyncTemplates && !serverConfig.SyncAssets {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "build."+metrics.PhaseGitSync)
+	start := time.Now()
+	err := git.HandleGitRemoteContent(ctx, serverConfig)
+	if err != nil {
+		err = fmt.Errorf("%w: failed to handle git remote content: %w", errGitSync, err)
+	} else if err = git.HandleSyncFromRepo(ctx, serverConfig); err != nil {
+		err = fmt.Errorf("%w: failed to sync from repo: %w", errGitSync, err)
+	}
+	tracing.End(span, err)
+
+	// a superseded build is not a failed sync
+	if !errors.Is(err, context.Canceled) {
+		metrics.ObserveBuildPhase(metrics.PhaseGitSync, time.Since(start))
+		metrics.RecordGitSync(err)
+	}
+	return err
+}
+
+// runBuildPhase runs a phase of a build in its own span and records its duration
+func runBuildPhase(ctx context.Context, phase string, run func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "build."+phase)
+	start := time.Now()
+	err := run(ctx)
+	metrics.ObserveBuildPhase(phase, time.Since(start))
+	tracing.End(span, err)
+	return err
+}
+
+// generate generates a new build of the site into its own directory, the live
+// build keeps being served meanwhile. The steps not selected are copied from
+// previousBuildPath. The build is validated, made live and its app snapshot
+// returned. Its trigger, content commit and the warnings and errors captured
+// by ctx, see startBuildCapture, are recorded in its manifest
+func generate(
+	ctx context.Context,
+	app *handler.App,
+	callerName string,
+	previousBuildPath string,
+	steps build.Steps,
+) (*handler.App, error) {
+	appLogger := app.Logger
+
+	newBuild, err := build.New(app.ServerConfig.GeneratedPath)
+	if err != nil {
+		return nil, err
+	}
+	appLogger.Info("Generating build %s in %s", newBuild.ID, newBuild.Path)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("mdserve.build_id", newBuild.ID))
+	newBuild.Trigger = callerName
+	newBuild.Commit, err = git.GetContentCommit(app.ServerConfig)
+	if err != nil {
+		appLogger.Debug("Content is not in a git repository, the build has no commit: %v", err)
+	}
+
+	pages, err := generateBuild(ctx, app, newBuild.Path, previousBuildPath, steps)
+	if err == nil {
+		err = build.Validate(newBuild.Path, app.ServerConfig)
+	}
+	var snapshot *handler.App
+	if err == nil {
+		// parsing the templates and loading the site map of the build also validates it
+		snapshot, err = handler.LoadApp(app.ServerConfig, app.SiteConfig, app.Logger, newBuild.Path)
+	}
+	newBuild.Warnings, newBuild.Errors = logger.CaptureFromContext(ctx).Stop()
+	if err == nil {
+		err = newBuild.Finish(pages)
+	}
+	if err == nil {
+		// a superseded build is not made live
+		err = ctx.Err()
+	}
+	if err == nil {
+		snapshot.Build = newBuild
+	}
+	if err != nil {
+		if removeErr := newBuild.Remove(); removeErr != nil {
+			appLogger.Error("Failed to remove failed build %s: %v", newBuild.ID, removeErr)
+		}
+		if !errors.Is(err, context.Canceled) {
+			newBuild.FinishedAt = time.Now().UTC()
+			newBuild.Error = err.Error()
+			failedBuilds.Add(*newBuild)
+		}
+		return nil, fmt.Errorf("build %s failed: %w", newBuild.ID, err)
+	}
+
+	err = build.Activate(app.ServerConfig.GeneratedPath, newBuild.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// startBuildCapture starts capturing the warnings and errors of a build, from
+// its git sync on. Only the loggers tagged with the returned context are
+// captured, so the requests served meanwhile are not recorded as warnings of
+// the build
+func startBuildCapture(ctx context.Context) (context.Context, func()) {
+	capture := logger.StartCapture()
+	return capture.Context(ctx), func() { capture.Stop() }
+}
+
+// regenerate generates a new build of the site and publishes its app snapshot
+// to live, then deletes the builds that are no longer kept. A failed
+// generation keeps the previous snapshot live. Generations must not overlap,
+// after startup they are run by the build coordinator
+func regenerate(ctx context.Context, live *handler.Live, callerName string) (err error) {
+	ctx, span := tracing.Start(ctx, "build", attribute.String("mdserve.trigger", callerName))
+	defer func() { tracing.End(span, err) }()
+	ctx, stopCapture := startBuildCapture(ctx)
+	defer stopCapture()
+
+	app, err := prelimSetup(ctx, callerName)
+	if err != nil {
+		return err
+	}
+	live.Publish(app)
+
+	err = build.Prune(app.ServerConfig.GeneratedPath, app.ServerConfig.BuildsToKeep, servedBuilds(live)...)
+	if err != nil {
+		app.Logger.Warn("Failed to delete old builds: %v", err)
+	}
+	return nil
+}
+
+// servedBuilds returns the ids of the builds of the live and previous
+// snapshots. The previous snapshot still serves the requests in flight during
+// a swap, so its build must not be deleted
+func servedBuilds(live *handler.Live) []string {
+	var ids []string
+	for _, app := range []*handler.App{live.App(), live.Previous()} {
+		if app != nil && app.Build != nil {
+			ids = append(ids, app.Build.ID)
+		}
+	}
+	return ids
+}
+
+// rollback makes the kept build id live again and publishes its app snapshot
+// with the configs of the live snapshot
+func rollback(live *handler.Live, id string) error {
+	previous := live.App()
+	generatedPath := previous.ServerConfig.GeneratedPath
+	kept, err := build.Load(generatedPath, id)
+	if err != nil {
+		return err
+	}
+
+	// loading the snapshot first leaves the live build untouched if the kept one is broken
+	snapshot, err := handler.LoadApp(
+		previous.ServerConfig,
+		previous.SiteConfig,
+		logger.New("Admin", previous.ServerConfig.LogLevel),
+		kept.Path,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load build %s: %w", id, err)
+	}
+	snapshot.Build = kept
+
+	if err := build.Rollback(generatedPath, id); err != nil {
+		return err
+	}
+	live.Publish(snapshot)
+	return nil
+}
+
+// clearCaches deletes the kept builds other than the live and previous ones,
+// so the rollback targets too, and the clone of the git remote content, which
+// the next generation clones again
+func clearCaches(live *handler.Live, serverConfig *config.ServerConfig) error {
+	if err := build.Prune(serverConfig.GeneratedPath, 0, servedBuilds(live)...); err != nil {
+		return err
+	}
+	if serverConfig.GitRemoteContentURL != "" {
+		if err := os.RemoveAll(constants.GitRemoteContentDirectory); err != nil {
+			return fmt.Errorf("failed to delete the git remote content clone: %w", err)
 		}
 	}
+	return nil
+}
+
+// runBuild runs a build request of the build coordinator: a full
+// regeneration, or a rebuild of the changed steps
+func runBuild(ctx context.Context, live *handler.Live, request build.Request) error {
+	if request.Full {
+		return regenerate(ctx, live, request.Caller)
+	}
+	return rebuild(ctx, live, request.Caller, request.Steps)
+}
+
+// rebuild generates a new build after sources of the live snapshot changed,
+// ex. in the serve --watch mode. Only the steps are generated, the rest is
+// copied from the live build. The configs are not reloaded
+func rebuild(ctx context.Context, live *handler.Live, callerName string, steps build.Steps) (err error) {
+	ctx, span := tracing.Start(ctx, "build", attribute.String("mdserve.trigger", callerName))
+	defer func() { tracing.End(span, err) }()
+	ctx, stopCapture := startBuildCapture(ctx)
+	defer stopCapture()
+
+	previous := live.App()
+	app := &handler.App{
+		ServerConfig: previous.ServerConfig,
+		SiteConfig:   previous.SiteConfig,
+		Logger:       logger.New(callerName, previous.ServerConfig.LogLevel).Ctx(ctx),
+		Handler:      handler.HandlePage,
+	}
 
-	// Delete the generated path
-	err = files.DeleteDirectoryContents(app.ServerConfig.GeneratedPath)
+	snapshot, err := generate(ctx, app, callerName, previous.BuildPath, steps)
 	if err != nil {
-		appLogger.Fatal("Failed to delete generated path: %v", err)
+		return err
 	}
+	live.Publish(snapshot)
 
-	err = git.HandleSyncFromRepo(app.ServerConfig)
+	err = build.Prune(snapshot.ServerConfig.GeneratedPath, snapshot.ServerConfig.BuildsToKeep, servedBuilds(live)...)
 	if err != nil {
-		appLogger.Fatal("Failed to sync from repo: %v", err)
+		snapshot.Logger.Warn("Failed to delete old builds: %v", err)
 	}
+	return nil
+}
+
+// watchRequest returns the build request for changes to the sources of the
+// site. Config changes regenerate the whole site, as on SIGHUP
+func watchRequest(changes watch.Change) build.Request {
+	return build.Request{
+		Caller: "Watch",
+		Full:   changes.Has(watch.ConfigChanged),
+		Steps: build.Steps{
+			HTML:       changes.Has(watch.ContentChanged),
+			Assets:     changes.Has(watch.AssetsChanged),
+			UserStatic: changes.Has(watch.UserStaticChanged),
+			Templates:  changes.Has(watch.TemplatesChanged),
+			SiteMap:    changes.Has(watch.ContentChanged),
+		},
+	}
+}
+
+// generateBuild writes the HTML, assets, templates, site map and navigation
+// of the site to buildPath and returns the number of pages. The steps not
+// selected are copied from previousBuildPath
+func generateBuild(
+	ctx context.Context,
+	app *handler.App,
+	buildPath string,
+	previousBuildPath string,
+	steps build.Steps,
+) (int, error) {
+	appLogger := app.Logger
 
 	// if HTML Compilation mode is static, compile the HTML files
 	if app.ServerConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
-		appLogger.Info("Compiling static HTML files")
-		mdFiles, err := htmlcompiler.GetMDFiles(app.ServerConfig.ContentPath)
+		if steps.HTML {
+			appLogger.Info("Compiling static HTML files")
+			mdFiles, err := htmlcompiler.GetMDFiles(app.ServerConfig.ContentPath)
+			if err != nil {
+				return 0, fmt.Errorf("failed to get MD files: %w", err)
+			}
+
+			err = runBuildPhase(ctx, metrics.PhaseCompile, func(ctx context.Context) error {
+				return htmlcompiler.CompileHTMLFiles(ctx, mdFiles, app.SiteConfig, app.ServerConfig, buildPath)
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to compile HTML files: %w", err)
+			}
+
+			appLogger.Info("Static HTML files compiled successfully")
+		} else if err := copyFromBuild(previousBuildPath, buildPath, constants.HTMLFilesPath); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if steps.Assets {
+		if err := generateAssets(ctx, app, buildPath); err != nil {
+			return 0, err
+		}
+	} else if err := copyFromBuild(previousBuildPath, buildPath, constants.GeneratedAssetsPath); err != nil {
+		return 0, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// move all user-static assets to the generated path
+	if steps.UserStatic {
+		userStaticGeneratedPath := filepath.Join(buildPath, constants.UserStaticPath)
+		logger.Info("Moving user-static assets to generated path: %s", userStaticGeneratedPath)
+		err := assets.MoveAssets(app.ServerConfig.UserStaticPath, userStaticGeneratedPath)
 		if err != nil {
-			appLogger.Fatal("Failed to get MD files: %v", err)
+			return 0, fmt.Errorf("failed to move user-static assets: %w", err)
+		}
+		logger.Info("User-static assets moved successfully")
+	} else if err := copyFromBuild(previousBuildPath, buildPath, constants.UserStaticPath); err != nil {
+		return 0, err
+	}
+
+	if steps.Templates {
+		templatesGeneratedPath := filepath.Join(buildPath, constants.TemplatesPath)
+		logger.Info("Moving templates to generated path: %s", templatesGeneratedPath)
+		err := assets.MoveAssets(app.ServerConfig.TemplatesPath, templatesGeneratedPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to move templates: %w", err)
+		}
+		logger.Info("Templates moved successfully")
+	} else if err := copyFromBuild(previousBuildPath, buildPath, constants.TemplatesPath); err != nil {
+		return 0, err
+	}
+
+	// the static mode serves the precompressed assets, copied ones already have them
+	if app.ServerConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
+		var dirs []string
+		if steps.Assets {
+			dirs = append(dirs, filepath.Join(buildPath, constants.GeneratedAssetsPath))
 		}
+		if steps.UserStatic {
+			dirs = append(dirs, filepath.Join(buildPath, constants.UserStaticPath))
+		}
+		if len(dirs) > 0 {
+			err := runBuildPhase(ctx, metrics.PhasePrecompression, func(ctx context.Context) error {
+				return compression.Precompress(ctx, dirs...)
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to precompress assets: %w", err)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
-		err = htmlcompiler.CompileHTMLFiles(mdFiles, app.SiteConfig, app.ServerConfig)
+	if !steps.SiteMap {
+		for _, name := range []string{constants.SiteMapPath, constants.NavigationPath} {
+			if err := copyFromBuild(previousBuildPath, buildPath, name); err != nil {
+				return 0, err
+			}
+		}
+		siteMap, err := htmlcompiler.LoadSiteMap(filepath.Join(buildPath, constants.SiteMapPath))
 		if err != nil {
-			appLogger.Fatal("Failed to compile HTML files: %v", err)
+			return 0, fmt.Errorf("failed to load site map: %w", err)
 		}
+		return len(*siteMap), nil
+	}
 
-		appLogger.Info("Static HTML files compiled successfully")
+	var siteMap *[]htmlcompiler.SiteMapEntry
+	err := runBuildPhase(ctx, metrics.PhaseSiteMap, func(ctx context.Context) (err error) {
+		siteMap, err = generateSiteMap(ctx, app, buildPath)
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
+	return len(*siteMap), nil
+}
 
-	// handle asset optimization
+// generateAssets copies the assets to buildPath and optimizes the images
+func generateAssets(ctx context.Context, app *handler.App, buildPath string) error {
 	logger.Info("Optimizing assets")
-	app.AssetsGeneratedPath = filepath.Join(
-		app.ServerConfig.GeneratedPath,
-		constants.GeneratedAssetsPath,
-	)
-	logger.Info("Moving assets to generated path: %s", app.AssetsGeneratedPath)
-	err = assets.MoveAssets(app.ServerConfig.AssetsPath, app.AssetsGeneratedPath)
+	assetsGeneratedPath := filepath.Join(buildPath, constants.GeneratedAssetsPath)
+	logger.Info("Moving assets to generated path: %s", assetsGeneratedPath)
+	err := assets.MoveAssets(app.ServerConfig.AssetsPath, assetsGeneratedPath)
 	if err != nil {
-		appLogger.Fatal("Failed to move assets: %v", err)
+		return fmt.Errorf("failed to move assets: %w", err)
 	}
 
 	// get all the assets that have been moved to the generated assets path
-	allAssets, err := files.GetAllFilesInDirectory(app.AssetsGeneratedPath)
+	allAssets, err := files.GetAllFilesInDirectory(assetsGeneratedPath)
 	if err != nil {
-		appLogger.Fatal("Failed to get all assets: %v", err)
+		return fmt.Errorf("failed to get all assets: %w", err)
 	}
 
 	// find all assets that can be optimized
 	optimizableAssets, err := assets.GetOptimizableAssets(allAssets)
 	if err != nil {
-		appLogger.Fatal("Failed to get optimizable assets: %v", err)
+		return fmt.Errorf("failed to get optimizable assets: %w", err)
 	}
 
 	siteManifestIconPaths, err := assets.GetIconPathsFromSiteWebmanifest(
 		filepath.Join(app.ServerConfig.AssetsPath, "site.webmanifest"),
 	)
 	if err != nil {
-		appLogger.Fatal("Failed to get site manifest icon paths: %v", err)
+		return fmt.Errorf("failed to get site manifest icon paths: %w", err)
 	}
 
 	logger.Debug("Site manifest icon paths: %v", siteManifestIconPaths)
 
-	err = assets.OptimizeAssets(optimizableAssets, siteManifestIconPaths, app.ServerConfig)
+	err = runBuildPhase(ctx, metrics.PhaseImageOptimization, func(ctx context.Context) error {
+		return assets.OptimizeAssets(ctx, optimizableAssets, siteManifestIconPaths, app.ServerConfig)
+	})
 	if err != nil {
-		appLogger.Fatal("Failed to optimize assets: %v", err)
+		return fmt.Errorf("failed to optimize assets: %w", err)
 	}
 
 	logger.Info("Assets optimized successfully")
+	return nil
+}
 
-	// move all user-static assets to the generated path
-	app.UserStaticGeneratedPath = filepath.Join(
-		app.ServerConfig.GeneratedPath,
-		constants.UserStaticPath,
-	)
-	logger.Info("Moving user-static assets to generated path: %s", app.UserStaticGeneratedPath)
-	err = assets.MoveAssets(app.ServerConfig.UserStaticPath, app.UserStaticGeneratedPath)
-	if err != nil {
-		appLogger.Fatal("Failed to move user-static assets: %v", err)
+// generateSiteMap writes the site map, redirect stubs and navigation of the
+// content to buildPath
+func generateSiteMap(ctx context.Context, app *handler.App, buildPath string) (*[]htmlcompiler.SiteMapEntry, error) {
+	appLogger := app.Logger
+	siteMapPath := filepath.Join(buildPath, constants.SiteMapPath)
+	logger.Info("Site map path: %s", siteMapPath)
+
+	// take page dates from git history when configured, falling back to the
+	// file modification dates when the history is unavailable
+	var contentHistory map[string]git.FileHistory
+	if app.ServerConfig.UsesGitContentDates() {
+		var err error
+		contentHistory, err = git.GetContentHistory(app.ServerConfig)
+		if err != nil {
+			appLogger.Warn("Failed to read git history, using file modification dates: %v", err)
+		}
 	}
-	logger.Info("User-static assets moved successfully")
 
-	app.TemplatesGeneratedPath = filepath.Join(
-		app.ServerConfig.GeneratedPath,
-		constants.TemplatesPath,
+	// generate the site map
+	siteMap, err := htmlcompiler.GenerateSiteMap(
+		ctx,
+		app.ServerConfig.ContentPath,
+		app.SiteConfig,
+		contentHistory,
 	)
-	logger.Info("Moving templates to generated path: %s", app.TemplatesGeneratedPath)
-	err = assets.MoveAssets(app.ServerConfig.TemplatesPath, app.TemplatesGeneratedPath)
 	if err != nil {
-		appLogger.Fatal("Failed to move templates: %v", err)
+		return nil, fmt.Errorf("failed to generate site map: %w", err)
+	}
+	err = htmlcompiler.SaveSiteMap(siteMap, siteMapPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save site map: %w", err)
 	}
-	logger.Info("Templates moved successfully")
+	logger.Info("Site map saved successfully to %s", siteMapPath)
 
-	logger.Info("Generated path: %s", app.ServerConfig.GeneratedPath)
-	siteMapPath := filepath.Join(app.ServerConfig.GeneratedPath, constants.SiteMapPath)
-	logger.Info("Site map path: %s", siteMapPath)
+	// export aliases and redirects as meta refresh pages alongside the static HTML
+	if app.ServerConfig.HTMLCompilationMode == constants.HTMLCompilationModeStatic {
+		redirects := htmlcompiler.GetStaticRedirects(*siteMap, app.SiteConfig.Redirects)
+		err = htmlcompiler.WriteRedirectStubs(ctx, buildPath, redirects, app.SiteConfig.Site.URLs.TrailingSlash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write redirect stubs: %w", err)
+		}
+		logger.Info("Wrote %d redirect stubs", len(redirects))
+	}
 
-	// generate the site map
-	siteMap, err := htmlcompiler.GenerateSiteMap(app.ServerConfig.ContentPath, app.SiteConfig)
+	if app.SiteConfig.Site.Navigation.Enabled {
+		navigation, err := htmlcompiler.GenerateNavigation(
+			app.ServerConfig.ContentPath,
+			*siteMap,
+			app.SiteConfig.Site.URLs.Slugify,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate navigation: %w", err)
+		}
+		navigationPath := filepath.Join(buildPath, constants.NavigationPath)
+		err = htmlcompiler.SaveNavigation(navigation, navigationPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save navigation: %w", err)
+		}
+		logger.Info("Navigation saved successfully to %s", navigationPath)
+	}
+
+	return siteMap, nil
+}
+
+// copyFromBuild copies a file or directory of the previous build to buildPath,
+// parts the previous build does not have are skipped
+func copyFromBuild(previousBuildPath string, buildPath string, name string) error {
+	sourcePath := filepath.Join(previousBuildPath, name)
+	info, err := os.Stat(sourcePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		appLogger.Fatal("Failed to generate site map: %v", err)
+		return fmt.Errorf("failed to read %s of the previous build: %w", name, err)
+	}
+
+	destinationPath := filepath.Join(buildPath, name)
+	if info.IsDir() {
+		err = files.RecursivelyCopyDirectory(sourcePath, destinationPath)
+	} else {
+		err = files.CopyFile(sourcePath, destinationPath, true)
 	}
-	err = htmlcompiler.SaveSiteMap(siteMap, siteMapPath)
 	if err != nil {
-		appLogger.Fatal("Failed to save site map: %v", err)
+		return fmt.Errorf("failed to copy %s of the previous build: %w", name, err)
+	}
+	return nil
+}
+
+// parseArgs parses the command line: mdserve [serve] [--watch]
+func parseArgs(args []string) (watchMode bool, err error) {
+	if len(args) > 0 && args[0] == "serve" {
+		args = args[1:]
 	}
-	logger.Info("Site map saved successfully to %s", siteMapPath)
 
-	return app, nil
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.BoolVar(&watchMode, "watch", false,
+		"rebuild on changes to the content, templates, assets, user-static and configs and reload open browsers")
+	if err := flags.Parse(args); err != nil {
+		return false, err
+	}
+	if flags.NArg() > 0 {
+		return false, fmt.Errorf("unknown command %q, usage: mdserve [serve] [--watch]", flags.Arg(0))
+	}
+	return watchMode, nil
 }
 
 func main() {
 	appLogger := logger.New("Initial Setup", logger.DebugLevel)
+
+	watchMode, err := parseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		appLogger.Fatal("%v", err)
+	}
+
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		// Check if running in Docker (common indicators)
@@ -215,42 +665,148 @@ func main() {
 		}
 	}
 
-	app, err := prelimSetup("Main")
-	if err != nil {
-		appLogger.Fatal("Failed to perform prelim setup: %v", err)
-	}
+	// every generation publishes a new app snapshot with its own configs,
+	// templates and site map, requests are served by the latest one
+	var live *handler.Live
+	// in watch mode, pages reload themselves after each rebuild
+	reloader := handler.NewReloader()
+
+	// after startup, builds from the cron, SIGHUP, webhooks and the watcher
+	// run one at a time through the coordinator
+	coordinator := build.NewCoordinator(func(ctx context.Context, request build.Request) error {
+		err := runBuild(ctx, live, request)
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			appLogger.Error("Failed to regenerate the site: %v", err)
+			return err
+		}
+		if watchMode {
+			reloader.Reload()
+		}
+		return nil
+	}, build.DefaultDebounce)
+	defer coordinator.Close()
 
-	app.Logger.Info("Loading HTML templates...")
+	// webhooks regenerate the site in the background
+	triggerRegenerate := func(callerName string) {
+		coordinator.Trigger(build.Request{Caller: callerName, Full: true})
+	}
 
-	app.Templates = template.New("").Funcs(template.FuncMap{
-		"table_of_contents_href": func(text string) string {
-			// lower and replace all non-alphanumeric characters (except spaces and hyphens) with ""
-			re := regexp.MustCompile(`[^a-zA-Z0-9 \-]`)
-			cleanedText := re.ReplaceAllString(strings.TrimSpace(text), "")
-			// replace spaces with hyphens
-			cleanedText = strings.ReplaceAll(cleanedText, " ", "-")
-			return strings.ToLower(cleanedText)
+	adminOperations := handler.AdminOperations{
+		Regenerate: triggerRegenerate,
+		Rollback: func(id string) error {
+			return coordinator.Do(func() error {
+				return rollback(live, id)
+			})
 		},
-		"is_last": func(index int, length int) bool {
-			return index == int(length)-1
-		},
-		"array_to_string": func(array []string) string {
-			return strings.Join(array, ", ")
+		ClearCaches: func() error {
+			serverConfig := live.App().ServerConfig
+			err := coordinator.Do(func() error {
+				return clearCaches(live, serverConfig)
+			})
+			// git page dates are read from the clone, which a generation clones again
+			if err == nil && serverConfig.GitRemoteContentURL != "" {
+				triggerRegenerate("Admin")
+			}
+			return err
 		},
-	})
-	app.Templates, err = app.Templates.ParseGlob(app.TemplatesGeneratedPath + "/*.html")
-	if err != nil {
-		app.Logger.Fatal("Failed to parse templates: %v", err)
+		FailedBuilds: failedBuilds.List,
 	}
 
-	// load templates from layout_templates subdirectory
-	layoutTemplatesPath := filepath.Join(app.TemplatesGeneratedPath, "layout_templates")
-	if _, err := os.Stat(layoutTemplatesPath); err == nil {
-		app.Templates, err = app.Templates.ParseGlob(layoutTemplatesPath + "/*.html")
+	newHandler := func(app *handler.App) http.Handler {
+		return newServerHandler(app, adminOperations)
+	}
+	if watchMode {
+		newHandler = func(app *handler.App) http.Handler {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET "+handler.ReloadEventsPath, reloader.HandleEvents)
+			mux.Handle("/", reloader.InjectScript(newServerHandler(app, adminOperations)))
+			return mux
+		}
+	}
+
+	live = handler.NewLive(newHandler)
+	health := handler.NewHealth(live)
+
+	// the server starts before the first generation so the health endpoints
+	// answer meanwhile, the site is served once it is live
+	serverConfig, err := config.LoadServerConfig()
+	if err != nil {
+		appLogger.Fatal("Failed to load server config: %v", err)
+	}
+	if err := logger.Configure(serverConfig.LoggingConfig()); err != nil {
+		appLogger.Fatal("Failed to initialize logger: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.HandleHealthz)
+	mux.HandleFunc("GET /readyz", health.HandleReadyz)
+	mux.HandleFunc("GET /version", health.HandleVersion)
+	// the metrics and traces cover the requests served by the snapshots, not the probes.
+	// The responses are compressed outside of the snapshots, after the reload
+	// script of the watch mode is inserted
+	var site http.Handler = compression.Middleware(live)
+	// exports the spans still batched when the server stops
+	shutdownTracing := func(context.Context) error { return nil }
+	if serverConfig.TracingEnabled {
+		shutdownTracing, err = tracing.Setup(context.Background(), serverConfig.TracingEndpoint)
 		if err != nil {
-			app.Logger.Fatal("Failed to parse layout templates: %v", err)
+			appLogger.Fatal("Failed to set up tracing: %v", err)
 		}
+		site = tracing.Middleware(site)
+	}
+	if serverConfig.MetricsEnabled {
+		mux.Handle("GET /metrics", metrics.Handler())
+		site = metrics.Middleware(site)
+	}
+	mux.Handle("/", site)
+
+	trustedProxies, err := serverConfig.TrustedProxyPrefixes()
+	if err != nil {
+		appLogger.Fatal("Failed to parse trusted proxies: %v", err)
 	}
+	accessLogger := logger.New("Access", serverConfig.LogLevel)
+
+	// add timeout to the server
+	srv := &http.Server{
+		Addr:         ":" + strconv.Itoa(serverConfig.Port),
+		Handler:      handler.AccessLog(accessLogger, trustedProxies, mux),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- srv.ListenAndServe()
+	}()
+	appLogger.Info(
+		"Starting MDServe on port %d in %s mode",
+		serverConfig.Port,
+		serverConfig.HTMLCompilationMode,
+	)
+
+	// SIGHUP regenerates the site, ex. after editing the configs. A SIGHUP
+	// received before the first generation is live waits for it, instead of
+	// ending the process
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	// a failed git sync, ex. the git host being down, is retried until it
+	// succeeds, other failures of the first generation are fatal
+	for retryDelay := initialSyncRetryDelay; ; retryDelay = min(2*retryDelay, maxSyncRetryDelay) {
+		err := regenerate(context.Background(), live, "Main")
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errGitSync) {
+			appLogger.Fatal("Failed to perform prelim setup: %v", err)
+		}
+		health.SetSyncError(err)
+		appLogger.Error("Initial git sync failed, retrying in %s: %v", retryDelay, err)
+		time.Sleep(retryDelay)
+	}
+	health.SetSyncError(nil)
+	app := live.App()
 
 	if app.ServerConfig.GenerationCronEnabled {
 		app.Logger.Info(
@@ -264,12 +820,9 @@ func main() {
 		))
 
 		// Add hourly job
-		_, err = c.AddFunc(app.ServerConfig.GenerationCronInterval, func() {
+		_, err := c.AddFunc(app.ServerConfig.GenerationCronInterval, func() {
 			app.Logger.Info("Running hourly cron job...")
-			_, err = prelimSetup("Generation Cron")
-			if err != nil {
-				app.Logger.Fatal("Failed to perform prelim setup: %v", err)
-			}
+			triggerRegenerate("Generation Cron")
 		})
 		if err != nil {
 			app.Logger.Fatal("Failed to schedule cron job: %v", err)
@@ -278,58 +831,120 @@ func main() {
 		c.Start()
 		app.Logger.Info("Cron scheduler started - hourly job registered")
 
-		// Ensure cron stops when main exits
+		// Ensure cron stops when main exits, once its running job returned
 		defer func() {
-			err := c.Stop()
-			if err != nil {
-				app.Logger.Fatal("Failed to stop cron scheduler: %v", err)
-			}
+			<-c.Stop().Done()
 		}()
 	}
 
+	go func() {
+		for range hangups {
+			app.Logger.Info("Received SIGHUP, regenerating the site...")
+			triggerRegenerate("SIGHUP")
+		}
+	}()
+
+	if watchMode {
+		watcher, err := watch.New(app.ServerConfig, config.LocalConfigPaths())
+		if err != nil {
+			app.Logger.Fatal("Failed to watch the site: %v", err)
+		}
+		defer func() {
+			_ = watcher.Close()
+		}()
+
+		go watcher.Run(func(changes watch.Change) {
+			app.Logger.Info("Changes to %s, rebuilding the site...", changes)
+			coordinator.Trigger(watchRequest(changes))
+		})
+		app.Logger.Info("Watching the site for changes")
+	}
+
+	app.Logger.Info("MDServe is ready")
+
+	// SIGINT and SIGTERM stop the server once the requests in flight are served
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case serverErr := <-serverErrors:
+		if err := shutdownTracing(context.Background()); err != nil {
+			app.Logger.Error("Failed to export the remaining spans: %v", err)
+		}
+		app.Logger.Fatal("Server failed: %v", serverErr)
+	case sig := <-stop:
+		app.Logger.Info("Received %s, shutting down...", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	coordinator.Close()
+	if err := srv.Shutdown(ctx); err != nil {
+		app.Logger.Error("Failed to stop the server: %v", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		app.Logger.Error("Failed to export the remaining spans: %v", err)
+	}
+}
+
+// newServerHandler returns the routes of an app snapshot wrapped in the
+// middleware. Webhooks and the admin API change the live build with operations
+func newServerHandler(app *handler.App, operations handler.AdminOperations) http.Handler {
 	mux := http.NewServeMux()
 
 	// Serve Optimized System Assets (Mapped to /assets/)
 	mux.Handle(
 		"GET /assets/",
-		http.StripPrefix("/assets/", http.FileServer(http.Dir(app.AssetsGeneratedPath))),
+		http.StripPrefix("/assets/", compression.FileServer(app.AssetsGeneratedPath)),
 	)
 
 	// Serve User Static Assets (Mapped to /user-static/)
-	// Assuming this config exists in ServerConfig
-	userStaticPath := "user-static"
 	mux.Handle(
 		"GET /user-static/",
-		http.StripPrefix("/user-static/", http.FileServer(http.Dir(userStaticPath))),
+		http.StripPrefix("/user-static/", compression.FileServer(app.UserStaticGeneratedPath)),
 	)
 
 	mux.HandleFunc("GET /sitemap.xml", handler.HandleSitemap(app))
+	mux.HandleFunc("GET /sitemap/{file}", handler.HandleSitemapPage(app))
+	mux.HandleFunc("GET /robots.txt", handler.HandleRobots(app))
+	mux.HandleFunc("GET /llms.txt", handler.HandleLLMsTxt(app))
+
+	// Read-only JSON content API
+	mux.HandleFunc("GET /api/pages", handler.HandleAPIPages(app))
+	mux.HandleFunc("GET /api/pages/{path...}", handler.HandleAPIPage(app))
+	mux.HandleFunc("GET /api/tags", handler.HandleAPITags(app))
+
+	// Push webhook of the git remote content repository
+	if app.ServerConfig.GitWebhookEnabled() {
+		mux.HandleFunc("POST /hooks/git", handler.HandleGitWebhook(app, operations.Regenerate))
+	}
+
+	// Token protected admin API and dashboard
+	if app.ServerConfig.AdminEnabled() {
+		admin := func(next http.HandlerFunc) http.HandlerFunc {
+			return handler.RequireAdminToken(app, next)
+		}
+		mux.HandleFunc("GET /admin/{$}", admin(handler.HandleAdminDashboard(app, operations)))
+		mux.HandleFunc("GET /admin/builds", admin(handler.HandleAdminBuilds(app, operations)))
+		mux.HandleFunc("POST /admin/builds", admin(handler.HandleAdminRebuild(app, operations)))
+		mux.HandleFunc("POST /admin/builds/{id}/rollback", admin(handler.HandleAdminRollback(app, operations)))
+		mux.HandleFunc("POST /admin/cache/clear", admin(handler.HandleAdminClearCaches(app, operations)))
+		mux.HandleFunc("GET /admin/config", admin(handler.HandleAdminConfig(app)))
+	}
+
+	// Date based archive pages
+	mux.HandleFunc("GET /archive/{$}", handler.HandleArchive(app))
+	mux.HandleFunc("GET /archive/{year}/{$}", handler.HandleArchive(app))
+	mux.HandleFunc("GET /archive/{year}/{month}/{$}", handler.HandleArchive(app))
+
+	// Author profile pages
+	mux.HandleFunc("GET /authors/{id}/{$}", handler.HandleAuthor(app))
 
 	// Main Page Handler
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
 		app.Handler(app, w, r)
 	})
 
-	// --- 5. Start Server ---
-	port := app.ServerConfig.Port
-	app.Logger.Info(
-		"Starting MDServe on port %d in %s mode",
-		port,
-		app.ServerConfig.HTMLCompilationMode,
-	)
-
 	// Wrap mux with middleware
 	// Order: CORS -> Cache -> Mux
-	srvHandler := handler.AddCORSHeaders(handler.AddCacheHeaders(app, mux))
-
-	// add timeout to the server
-	srv := &http.Server{
-		Addr:         ":" + strconv.Itoa(port),
-		Handler:      srvHandler,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
-	if err := srv.ListenAndServe(); err != nil {
-		app.Logger.Fatal("Server failed: %v", err)
-	}
+	return handler.AddCORSHeaders(handler.AddCacheHeaders(app, mux))
 }